@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/mailer"
+)
+
+const accountDeletionGracePeriod = time.Duration(30*24) * time.Hour
+
+// handleDeleteAccount schedules the caller's account for deletion once they
+// confirm with their password, or with a recent login if they have none.
+// Until the purge job removes it, the account is hidden and logging in
+// restores it.
+func (cfg *apiConfig) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	if !cfg.confirmIdentity(w, r, user, params.Password, "delete_account") {
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot delete account")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err = qtx.RequestUserDeletion(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot delete account")
+		return
+	}
+	err = qtx.RevokeAllRefreshTokensForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot delete account")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot delete account")
+		return
+	}
+
+	purgeAfter := user.DeletionRequestedAt.Time.Add(accountDeletionGracePeriod)
+	cfg.audit(r, auditEvent{
+		Type:     auditAccountDeletionRequested,
+		UserID:   userID,
+		Metadata: map[string]any{"purge_after": purgeAfter},
+	})
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy account will be deleted",
+		Body: fmt.Sprintf(
+			"Your Chirpy account and all of its chirps will be deleted for good on %s.\n\n"+
+				"Changed your mind? Log in before then and your account will be restored.",
+			purgeAfter.Format("January 2, 2006"),
+		),
+	})
+
+	type deletionResponse struct {
+		PurgeAfter time.Time `json:"purge_after"`
+	}
+
+	respondWithJson(w, 202, deletionResponse{PurgeAfter: purgeAfter})
+}
+
+// cancelAccountDeletion restores an account scheduled for deletion when its
+// owner logs in again.
+func (cfg *apiConfig) cancelAccountDeletion(r *http.Request, user database.User) {
+	if !user.DeletionRequestedAt.Valid {
+		return
+	}
+
+	err := cfg.db.CancelUserDeletion(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error cancelling deletion of user %s: %s", user.ID, err)
+		return
+	}
+	cfg.audit(r, auditEvent{Type: auditAccountDeletionCancelled, UserID: user.ID})
+}
+
+// runAccountPurge deletes accounts past their grace period every interval
+// until ctx is done.
+func runAccountPurge(ctx context.Context, db *database.Queries, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := purgeDeletedAccounts(ctx, db)
+		if err != nil {
+			log.Printf("Error purging deleted accounts: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedAccounts hard-deletes accounts whose grace period is over.
+// Their chirps, tokens and other rows go with them through ON DELETE CASCADE.
+func purgeDeletedAccounts(ctx context.Context, db *database.Queries) (int, error) {
+	ids, err := db.PurgeDeletedUsers(ctx, sql.NullTime{Time: time.Now().Add(-accountDeletionGracePeriod), Valid: true})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		err = db.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+			EventType: auditAccountPurged,
+			UserID:    nullUUID(id),
+			Metadata:  []byte("{}"),
+		})
+		if err != nil {
+			log.Printf("Error recording purge of user %s: %s", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("Purged %d deleted accounts", len(ids))
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// loginWithoutPassword logs user in the way a login through an identity
+// provider does.
+func (ts *testServer) loginWithoutPassword(user database.User) User {
+	ts.t.Helper()
+	rec := httptest.NewRecorder()
+	ts.cfg.respondWithTokens(rec, httptest.NewRequest("GET", "/api/oauth/test/callback", nil), user, "oidc")
+	expectStatus(ts.t, rec, 200)
+	return decodeResponse[User](ts.t, rec)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	rec := ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "I am the one who knocks."})
+	expectStatus(t, rec, 201)
+	chirp := decodeResponse[Chirp](t, rec)
+
+	rec = ts.request("DELETE", "/api/users", user.Token, map[string]string{"password": "wrong password"})
+	expectStatus(t, rec, 401)
+	rec = ts.request("DELETE", "/api/users", user.Token, map[string]string{"password": testPassword})
+	expectStatus(t, rec, 202)
+
+	rec = ts.request("GET", "/api/sessions", user.Token, nil)
+	expectStatus(t, rec, 401)
+	rec = ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil)
+	expectStatus(t, rec, 404)
+
+	// Logging in during the grace period restores the account.
+	ts.login("walt@breakingbad.com")
+	rec = ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil)
+	expectStatus(t, rec, 200)
+}
+
+func TestDeleteAccount_WithoutPassword(t *testing.T) {
+	ts := newTestServer(t)
+	user, err := ts.cfg.db.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          "walt@breakingbad.com",
+		HashedPassword: unsetPassword,
+	})
+	if err != nil {
+		t.Fatalf("cannot create user: %v", err)
+	}
+
+	stale := ts.loginWithoutPassword(user)
+	_, err = ts.cfg.conn.Exec("UPDATE refresh_tokens SET session_started_at = session_started_at - interval '1 hour' WHERE user_id = $1", user.ID)
+	if err != nil {
+		t.Fatalf("cannot backdate session: %v", err)
+	}
+	rec := ts.request("DELETE", "/api/users", stale.Token, map[string]string{})
+	expectStatus(t, rec, 403)
+
+	// Refreshing keeps the session, so it does not count as logging in.
+	rec = ts.request("POST", "/api/refresh", stale.RefreshToken, nil)
+	expectStatus(t, rec, 200)
+	refreshed := decodeResponse[testTokenPair](t, rec)
+	rec = ts.request("DELETE", "/api/users", refreshed.Token, map[string]string{})
+	expectStatus(t, rec, 403)
+
+	fresh := ts.loginWithoutPassword(user)
+	rec = ts.request("DELETE", "/api/users", fresh.Token, map[string]string{})
+	expectStatus(t, rec, 202)
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	other := ts.createUser("jesse@breakingbad.com")
+	rec := ts.request("DELETE", "/api/users", user.Token, map[string]string{"password": testPassword})
+	expectStatus(t, rec, 202)
+
+	purged, err := purgeDeletedAccounts(context.Background(), ts.cfg.db)
+	if err != nil || purged != 0 {
+		t.Fatalf("expected nothing purged within the grace period, got %d, %v", purged, err)
+	}
+
+	_, err = ts.cfg.conn.Exec("UPDATE users SET deletion_requested_at = NOW() - interval '31 days' WHERE id = $1", user.ID)
+	if err != nil {
+		t.Fatalf("cannot backdate deletion: %v", err)
+	}
+	purged, err = purgeDeletedAccounts(context.Background(), ts.cfg.db)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected 1 account purged, got %d, %v", purged, err)
+	}
+
+	rec = ts.request("POST", "/api/login", "", map[string]string{"email": "walt@breakingbad.com", "password": testPassword})
+	expectStatus(t, rec, 401)
+	rec = ts.request("GET", "/api/sessions", other.Token, nil)
+	expectStatus(t, rec, 200)
+}
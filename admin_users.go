@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+const impersonationTokenDuration = 15 * time.Minute
+
+func (cfg *apiConfig) handleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid uuid")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	err = cfg.accountLimiter.Reset(r.Context(), "account:"+strings.ToLower(user.Email))
+	if err != nil {
+		respondWithError(w, 500, "cannot unlock user")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:    auditAccountUnlocked,
+		ActorID: claimsFromContext(r.Context()).UserID,
+		UserID:  user.ID,
+	})
+	respondWithJson(w, 204, nil)
+}
+
+func (cfg *apiConfig) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid uuid")
+		return
+	}
+
+	type parameters struct {
+		Role string `json:"role"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	if !auth.ValidRole(params.Role) {
+		respondWithError(w, 400, "invalid role")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	oldRole := user.Role
+
+	user, err = cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: params.Role,
+	})
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditRoleChanged,
+		ActorID:  claimsFromContext(r.Context()).UserID,
+		UserID:   user.ID,
+		Metadata: map[string]any{"old_role": oldRole, "new_role": user.Role},
+	})
+	respondWithJson(w, 200, dbUserToUserStruct(user))
+}
+
+// handleImpersonateUser issues a short-lived access token for acting as
+// another user. Tokens are read-only unless allow_writes is set, and every
+// request made with one is audited.
+func (cfg *apiConfig) handleImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	actor := claimsFromContext(r.Context())
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid uuid")
+		return
+	}
+
+	type parameters struct {
+		Reason      string `json:"reason"`
+		AllowWrites bool   `json:"allow_writes"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	if params.Reason == "" {
+		respondWithError(w, 400, "reason is required")
+		return
+	}
+	if userID == actor.UserID {
+		respondWithError(w, 400, "cannot impersonate yourself")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if auth.HasRole(user.Role, auth.RoleAdmin) {
+		respondWithError(w, 403, "cannot impersonate an admin")
+		return
+	}
+
+	readOnly := !params.AllowWrites
+	token, err := cfg.keys.MakeImpersonationToken(user.ID, user.Role, actor.UserID, readOnly, impersonationTokenDuration)
+	if err != nil {
+		respondWithError(w, 500, "cannot generate access token")
+		return
+	}
+
+	err = cfg.recordAuditEvent(r, auditEvent{
+		Type:    auditImpersonationStarted,
+		ActorID: actor.UserID,
+		UserID:  user.ID,
+		Metadata: map[string]any{
+			"reason":    params.Reason,
+			"read_only": readOnly,
+		},
+	})
+	if err != nil {
+		log.Printf("Error auditing impersonation of %s by %s: %s", user.ID, actor.UserID, err)
+		respondWithError(w, 500, "cannot audit impersonation")
+		return
+	}
+
+	type impersonationResponse struct {
+		User      User      `json:"user"`
+		Token     string    `json:"token"`
+		ReadOnly  bool      `json:"read_only"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	respondWithJson(w, 201, impersonationResponse{
+		User:      dbUserToUserStruct(user),
+		Token:     token,
+		ReadOnly:  readOnly,
+		ExpiresAt: time.Now().Add(impersonationTokenDuration),
+	})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+type testImpersonation struct {
+	Token    string `json:"token"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// createAdmin signs up a user, makes them an admin and logs them in again
+// for a token carrying the role.
+func (ts *testServer) createAdmin(email string) User {
+	ts.t.Helper()
+	admin := ts.createUser(email)
+	_, err := ts.cfg.db.SetUserRole(context.Background(), database.SetUserRoleParams{
+		ID:   admin.ID,
+		Role: auth.RoleAdmin,
+	})
+	if err != nil {
+		ts.t.Fatalf("cannot make %s an admin: %v", email, err)
+	}
+	return ts.login(email)
+}
+
+func (ts *testServer) impersonate(admin, user User, allowWrites bool) testImpersonation {
+	ts.t.Helper()
+	rec := ts.request("POST", "/admin/users/"+user.ID.String()+"/impersonate", admin.Token, map[string]any{
+		"reason":       "support ticket 42",
+		"allow_writes": allowWrites,
+	})
+	expectStatus(ts.t, rec, 201)
+	return decodeResponse[testImpersonation](ts.t, rec)
+}
+
+func TestImpersonation_ReadOnly(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.createAdmin("gus@lospollos.com")
+	user := ts.createUser("walt@breakingbad.com")
+
+	impersonation := ts.impersonate(admin, user, false)
+	if !impersonation.ReadOnly {
+		t.Fatalf("expected a read-only token")
+	}
+
+	rec := ts.request("GET", "/api/sessions", impersonation.Token, nil)
+	expectStatus(t, rec, 200)
+	rec = ts.request("POST", "/api/chirps", impersonation.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 403)
+	rec = ts.request("DELETE", "/api/sessions", impersonation.Token, nil)
+	expectStatus(t, rec, 403)
+
+	// The token acts as the user, so it gets none of the admin's privileges.
+	rec = ts.request("GET", "/admin/audit-events", impersonation.Token, nil)
+	expectStatus(t, rec, 403)
+}
+
+func TestImpersonation_WritesExcludeCredentials(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.createAdmin("gus@lospollos.com")
+	user := ts.createUser("walt@breakingbad.com")
+
+	impersonation := ts.impersonate(admin, user, true)
+	if impersonation.ReadOnly {
+		t.Fatalf("expected a token allowing writes")
+	}
+
+	rec := ts.request("POST", "/api/chirps", impersonation.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 201)
+
+	// Even a token allowing writes cannot take over the account.
+	rec = ts.request("POST", "/api/tokens", impersonation.Token, map[string]any{
+		"name":   "backdoor",
+		"scopes": []string{auth.ScopeAccountWrite},
+	})
+	expectStatus(t, rec, 403)
+	rec = ts.request("POST", "/api/mfa/totp", impersonation.Token, nil)
+	expectStatus(t, rec, 403)
+	rec = ts.request("POST", "/api/users/me/password", impersonation.Token, map[string]string{
+		"current_password": testPassword,
+		"new_password":     "pollos hermanos",
+	})
+	expectStatus(t, rec, 403)
+	rec = ts.request("PATCH", "/api/users/me", impersonation.Token, map[string]string{"email": "heisenberg@breakingbad.com"})
+	expectStatus(t, rec, 403)
+}
+
+func TestImpersonation_RequiresAdmin(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	other := ts.createUser("jesse@breakingbad.com")
+
+	rec := ts.request("POST", "/admin/users/"+other.ID.String()+"/impersonate", user.Token, map[string]any{"reason": "curious"})
+	expectStatus(t, rec, 403)
+}
+
+// auditEvents lists the audit events of one type, as admin.
+func (ts *testServer) auditEvents(admin User, eventType string) []AuditEvent {
+	ts.t.Helper()
+	rec := ts.request("GET", "/admin/audit-events?event_type="+eventType, admin.Token, nil)
+	expectStatus(ts.t, rec, 200)
+	return decodeResponse[[]AuditEvent](ts.t, rec)
+}
+
+func TestUnlockUser(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.createAdmin("gus@lospollos.com")
+	user := ts.createUser("walt@breakingbad.com")
+
+	for range testAccountPolicy.MaxFailures {
+		rec := ts.request("POST", "/api/login", "", map[string]string{"email": "walt@breakingbad.com", "password": "wrong password"})
+		expectStatus(t, rec, 401)
+	}
+	rec := ts.request("POST", "/api/login", "", map[string]string{"email": "walt@breakingbad.com", "password": testPassword})
+	expectStatus(t, rec, 429)
+
+	rec = ts.request("POST", "/admin/users/"+user.ID.String()+"/unlock", admin.Token, nil)
+	expectStatus(t, rec, 204)
+	ts.login("walt@breakingbad.com")
+
+	events := ts.auditEvents(admin, auditAccountUnlocked)
+	if len(events) != 1 || events[0].ActorID == nil || *events[0].ActorID != admin.ID || events[0].UserID == nil || *events[0].UserID != user.ID {
+		t.Errorf("expected the unlock audited with the admin as actor, got %+v", events)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.createAdmin("gus@lospollos.com")
+	user := ts.createUser("walt@breakingbad.com")
+
+	rec := ts.request("PUT", "/admin/users/"+user.ID.String()+"/role", admin.Token, map[string]string{"role": auth.RoleModerator})
+	expectStatus(t, rec, 200)
+	err := runCommand(context.Background(), ts.cfg.db, []string{"promote", "walt@breakingbad.com", auth.RoleUser})
+	if err != nil {
+		t.Fatalf("unexpected error demoting user: %v", err)
+	}
+
+	events := ts.auditEvents(admin, auditRoleChanged)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 role changes audited, got %+v", events)
+	}
+	changes := map[string]bool{}
+	for _, event := range events {
+		if event.UserID == nil || *event.UserID != user.ID {
+			t.Errorf("expected the change audited for the user, got %+v", event)
+		}
+		var metadata struct {
+			OldRole string `json:"old_role"`
+			NewRole string `json:"new_role"`
+		}
+		err := json.Unmarshal(event.Metadata, &metadata)
+		if err != nil {
+			t.Fatalf("cannot decode metadata: %v", err)
+		}
+		changes[metadata.OldRole+"->"+metadata.NewRole] = event.ActorID != nil && *event.ActorID == admin.ID
+	}
+	if actorRecorded, ok := changes["user->moderator"]; !ok || !actorRecorded {
+		t.Errorf("expected the admin's change audited with them as actor, got %v", changes)
+	}
+	if _, ok := changes["moderator->user"]; !ok {
+		t.Errorf("expected the command line change audited, got %v", changes)
+	}
+}
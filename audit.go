@@ -0,0 +1,208 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// Audit event types.
+const (
+	auditLoginSucceeded           = "login.succeeded"
+	auditLoginFailed              = "login.failed"
+	auditLoginMFAChallenged       = "login.mfa_challenged"
+	auditTokenRefreshed           = "token.refreshed"
+	auditRefreshTokenReused       = "token.reuse_detected"
+	auditTokenRevoked             = "token.revoked"
+	auditSessionRevoked           = "session.revoked"
+	auditAllSessionsRevoked       = "session.revoked_all"
+	auditAccessTokenCreated       = "personal_access_token.created"
+	auditAccessTokenRevoked       = "personal_access_token.revoked"
+	auditOAuthAppRevoked          = "oauth_app.revoked"
+	auditPasswordChanged          = "password.changed"
+	auditPasswordReset            = "password.reset"
+	auditEmailChangeRequested     = "email.change_requested"
+	auditEmailVerified            = "email.verified"
+	auditMFAEnabled               = "mfa.enabled"
+	auditMFADisabled              = "mfa.disabled"
+	auditMembershipUpgraded       = "membership.upgraded"
+	auditRoleChanged              = "role.changed"
+	auditImpersonationStarted     = "impersonation.started"
+	auditImpersonatedRequest      = "impersonation.request"
+	auditAccountDeletionRequested = "account.deletion_requested"
+	auditAccountDeletionCancelled = "account.deletion_cancelled"
+	auditAccountPurged            = "account.purged"
+	auditAccountUnlocked          = "account.unlocked"
+	auditDataExportRequested      = "data_export.requested"
+	auditDataExportDownloaded     = "data_export.downloaded"
+	auditContentFilterCreated     = "content_filter.created"
+	auditContentFilterUpdated     = "content_filter.updated"
+	auditContentFilterDeleted     = "content_filter.deleted"
+)
+
+const defaultAuditEventLimit = 50
+const maxAuditEventLimit = 500
+
+// auditEvent is one entry for the audit log. ActorID is who did it, UserID
+// whose account it concerns; either may be uuid.Nil.
+type auditEvent struct {
+	Type     string
+	ActorID  uuid.UUID
+	UserID   uuid.UUID
+	Metadata map[string]any
+}
+
+// AuditEvent is a recorded audit log entry.
+type AuditEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	UserID    *uuid.UUID      `json:"user_id"`
+	IPAddress string          `json:"ip_address"`
+	UserAgent string          `json:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// recordAuditEvent stores event along with where the request came from.
+func (cfg *apiConfig) recordAuditEvent(r *http.Request, event auditEvent) error {
+	metadata := []byte("{}")
+	if event.Metadata != nil {
+		var err error
+		metadata, err = json.Marshal(event.Metadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cfg.db.CreateAuditEvent(r.Context(), database.CreateAuditEventParams{
+		EventType: event.Type,
+		ActorID:   nullUUID(event.ActorID),
+		UserID:    nullUUID(event.UserID),
+		IpAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+		Metadata:  metadata,
+	})
+}
+
+// audit records event, only logging failures. It is for events that happen
+// as a side effect of a request that should go ahead regardless.
+func (cfg *apiConfig) audit(r *http.Request, event auditEvent) {
+	err := cfg.recordAuditEvent(r, event)
+	if err != nil {
+		log.Printf("Error recording %s audit event: %s", event.Type, err)
+	}
+}
+
+func (cfg *apiConfig) handleListSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r, auth.ScopeAccountRead)
+	if !ok {
+		return
+	}
+
+	params, ok := parseAuditEventFilters(w, r)
+	if !ok {
+		return
+	}
+	params.UserID = nullUUID(userID)
+
+	cfg.respondWithAuditEvents(w, r, params)
+}
+
+// handleAdminListAuditEvents lists audit events filtered by the user_id,
+// actor_id, event_type, since and until query parameters.
+func (cfg *apiConfig) handleAdminListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	params, ok := parseAuditEventFilters(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	for name, target := range map[string]*uuid.NullUUID{"user_id": &params.UserID, "actor_id": &params.ActorID} {
+		if value := query.Get(name); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				respondWithError(w, 400, "invalid "+name)
+				return
+			}
+			*target = nullUUID(id)
+		}
+	}
+
+	cfg.respondWithAuditEvents(w, r, params)
+}
+
+// parseAuditEventFilters reads the event_type, since, until and limit query
+// parameters. Times are RFC 3339.
+func parseAuditEventFilters(w http.ResponseWriter, r *http.Request) (database.ListAuditEventsParams, bool) {
+	query := r.URL.Query()
+	params := database.ListAuditEventsParams{Limit: defaultAuditEventLimit}
+
+	if eventType := query.Get("event_type"); eventType != "" {
+		params.EventType = sql.NullString{String: eventType, Valid: true}
+	}
+	for name, target := range map[string]*sql.NullTime{"since": &params.Since, "until": &params.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondWithError(w, 400, "invalid "+name)
+				return params, false
+			}
+			*target = sql.NullTime{Time: t.UTC(), Valid: true}
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditEventLimit {
+			respondWithError(w, 400, "invalid limit")
+			return params, false
+		}
+		params.Limit = int32(limit)
+	}
+
+	return params, true
+}
+
+func (cfg *apiConfig) respondWithAuditEvents(w http.ResponseWriter, r *http.Request, params database.ListAuditEventsParams) {
+	dbEvents, err := cfg.db.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch events")
+		return
+	}
+
+	events := []AuditEvent{}
+	for _, event := range dbEvents {
+		events = append(events, dbAuditEventToAuditEventStruct(event))
+	}
+
+	respondWithJson(w, 200, events)
+}
+
+func dbAuditEventToAuditEventStruct(event database.AuditEvent) AuditEvent {
+	auditEvent := AuditEvent{
+		ID:        event.ID,
+		Type:      event.EventType,
+		IPAddress: event.IpAddress,
+		UserAgent: event.UserAgent,
+		Metadata:  event.Metadata,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.ActorID.Valid {
+		auditEvent.ActorID = &event.ActorID.UUID
+	}
+	if event.UserID.Valid {
+		auditEvent.UserID = &event.UserID.UUID
+	}
+	return auditEvent
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
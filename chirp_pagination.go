@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/chirps"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+const defaultChirpPageSize = 50
+const maxChirpPageSize = 100
+
+// chirpListing is what GET /api/chirps was asked for.
+type chirpListing struct {
+	authorID   uuid.NullUUID
+	descending bool
+	cursor     *chirps.Cursor
+	limit      int32
+}
+
+// handleListChirps lists chirps oldest first, or newest first with
+// sort=desc, optionally only those by author_id. Pages hold up to limit
+// chirps; the next one is fetched by passing the response's next_cursor as
+// cursor, and is also linked from the Link header.
+func (cfg *apiConfig) handleListChirps(w http.ResponseWriter, r *http.Request) {
+	listing, ok := parseChirpListing(w, r)
+	if !ok {
+		return
+	}
+
+	// Clients written before pagination get every chirp as a bare array.
+	if cfg.legacyChirpListing {
+		listing.limit = math.MaxInt32
+		listing.cursor = nil
+		dbChirps, err := cfg.listChirps(r, listing)
+		if err != nil {
+			respondWithError(w, 500, "cannot fetch chirps")
+			return
+		}
+
+		chirpList := []Chirp{}
+		for _, chirp := range dbChirps {
+			chirpList = append(chirpList, dbChirpToChirpStruct(chirp))
+		}
+		respondWithJson(w, 200, chirpList)
+		return
+	}
+
+	// One chirp more than the page holds tells whether there is a next page.
+	pageSize := listing.limit
+	listing.limit++
+	dbChirps, err := cfg.listChirps(r, listing)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch chirps")
+		return
+	}
+
+	type chirpPage struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	page := chirpPage{Chirps: []Chirp{}}
+	for i, chirp := range dbChirps {
+		if i == int(pageSize) {
+			last := dbChirps[i-1]
+			page.NextCursor = chirps.EncodeCursor(chirps.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+			break
+		}
+		page.Chirps = append(page.Chirps, dbChirpToChirpStruct(chirp))
+	}
+
+	if page.NextCursor != "" {
+		query := r.URL.Query()
+		query.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", "<"+cfg.baseURL+r.URL.Path+"?"+query.Encode()+`>; rel="next"`)
+	}
+	respondWithJson(w, 200, page)
+}
+
+// parseChirpListing reads the author_id, sort, cursor and limit query
+// parameters.
+func parseChirpListing(w http.ResponseWriter, r *http.Request) (chirpListing, bool) {
+	query := r.URL.Query()
+	listing := chirpListing{limit: defaultChirpPageSize}
+
+	if value := query.Get("author_id"); value != "" {
+		authorID, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, 400, "invalid author id")
+			return listing, false
+		}
+		listing.authorID = nullUUID(authorID)
+	}
+
+	switch strings.ToLower(query.Get("sort")) {
+	case "", "asc":
+	case "desc":
+		listing.descending = true
+	default:
+		respondWithError(w, 400, "sort must be asc or desc")
+		return listing, false
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := chirps.DecodeCursor(value)
+		if err != nil {
+			respondWithError(w, 400, "invalid cursor")
+			return listing, false
+		}
+		listing.cursor = &cursor
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxChirpPageSize {
+			respondWithError(w, 400, "invalid limit")
+			return listing, false
+		}
+		listing.limit = int32(limit)
+	}
+
+	return listing, true
+}
+
+// listChirps runs listing against the database, sorted and paginated there on
+// the (created_at, id) indexes.
+func (cfg *apiConfig) listChirps(r *http.Request, listing chirpListing) ([]database.Chirp, error) {
+	var after sql.NullTime
+	var afterID uuid.NullUUID
+	if listing.cursor != nil {
+		after = sql.NullTime{Time: listing.cursor.CreatedAt, Valid: true}
+		afterID = nullUUID(listing.cursor.ID)
+	}
+
+	if listing.descending {
+		return cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        listing.authorID,
+			BeforeCreatedAt: after,
+			BeforeID:        afterID,
+			Limit:           listing.limit,
+		})
+	}
+	return cfg.db.ListChirps(r.Context(), database.ListChirpsParams{
+		AuthorID:       listing.authorID,
+		AfterCreatedAt: after,
+		AfterID:        afterID,
+		Limit:          listing.limit,
+	})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// ChirpRevision is a body a chirp had before it was edited, from when it was
+// written until it was replaced.
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// chirpEditWindows is how long after posting authors may edit their chirps.
+type chirpEditWindows struct {
+	standard  time.Duration
+	chirpyRed time.Duration
+}
+
+func (windows chirpEditWindows) forUser(user database.User) time.Duration {
+	if user.IsChirpyRed {
+		return windows.chirpyRed
+	}
+	return windows.standard
+}
+
+// handleEditChirp replaces the body of one of the user's chirps, as long as
+// it is still within the edit window. The new body is validated and cleaned
+// like a new chirp's, and the old one is kept as a revision.
+func (cfg *apiConfig) handleEditChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+	if !cfg.checkCanWriteChirps(w, r, userID) {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid chirp uuid")
+		return
+	}
+
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot edit chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Locking the chirp keeps concurrent edits from losing a revision. The
+	// window is checked by the database, against the same clock that
+	// stamped created_at.
+	row, err := qtx.GetChirpForUpdate(r.Context(), database.GetChirpForUpdateParams{
+		ID:                chirpID,
+		EditWindowSeconds: cfg.chirpEdits.forUser(user).Seconds(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot edit chirp")
+		return
+	}
+	chirp := row.Chirp
+
+	if chirp.UserID != userID {
+		respondWithError(w, 403, "unauthorized")
+		return
+	}
+	if !row.Editable {
+		respondWithError(w, 403, "chirp can no longer be edited")
+		return
+	}
+
+	result := cfg.chirpPolicy.Apply(params.Body)
+	if len(result.Violations) > 0 {
+		cfg.recordFilterHits(r.Context(), userID, uuid.Nil, result.Hits)
+		respondWithChirpViolations(w, result.Violations)
+		return
+	}
+	if result.Body == chirp.Body {
+		respondWithJson(w, 200, dbChirpToChirpStruct(chirp))
+		return
+	}
+
+	writtenAt := chirp.CreatedAt
+	if chirp.EditedAt.Valid {
+		writtenAt = chirp.EditedAt.Time
+	}
+	_, err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: writtenAt,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot edit chirp")
+		return
+	}
+
+	chirp, err = qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		ID:   chirp.ID,
+		Body: result.Body,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot edit chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot edit chirp")
+		return
+	}
+	cfg.recordFilterHits(r.Context(), userID, chirp.ID, result.Hits)
+
+	respondWithJson(w, 200, dbChirpToChirpStruct(chirp))
+}
+
+// handleListChirpRevisions lists the bodies a chirp had before its current
+// one, oldest first.
+func (cfg *apiConfig) handleListChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid chirp uuid")
+		return
+	}
+
+	_, err = cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	dbRevisions, err := cfg.db.ListChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch revisions")
+		return
+	}
+
+	revisions := []ChirpRevision{}
+	for _, revision := range dbRevisions {
+		revisions = append(revisions, dbChirpRevisionToChirpRevisionStruct(revision))
+	}
+	respondWithJson(w, 200, revisions)
+}
+
+func dbChirpRevisionToChirpRevisionStruct(revision database.ChirpRevision) ChirpRevision {
+	return ChirpRevision{
+		ID:         revision.ID,
+		ChirpID:    revision.ChirpID,
+		Body:       revision.Body,
+		CreatedAt:  revision.CreatedAt,
+		ReplacedAt: revision.ReplacedAt,
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// backdateChirp moves a chirp's creation into the past, as the database
+// clock sees it.
+func (ts *testServer) backdateChirp(id uuid.UUID, age time.Duration) {
+	ts.t.Helper()
+	_, err := ts.cfg.conn.Exec("UPDATE chirps SET created_at = NOW() - make_interval(secs => $1) WHERE id = $2", age.Seconds(), id)
+	if err != nil {
+		ts.t.Fatalf("cannot backdate chirp: %v", err)
+	}
+}
+
+func TestEditChirp(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	other := ts.createUser("jesse@breakingbad.com")
+	rec := ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "I am the one who knocks."})
+	expectStatus(t, rec, 201)
+	chirp := decodeResponse[Chirp](t, rec)
+	path := "/api/chirps/" + chirp.ID.String()
+
+	rec = ts.request("PATCH", path, other.Token, map[string]string{"body": "Yeah, science!"})
+	expectStatus(t, rec, 403)
+
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 200)
+	if edited := decodeResponse[Chirp](t, rec); edited.Body != "Say my name." || !edited.Edited {
+		t.Errorf("expected the edited chirp, got %s", rec.Body)
+	}
+
+	rec = ts.request("GET", path+"/revisions", "", nil)
+	expectStatus(t, rec, 200)
+	revisions := decodeResponse[[]ChirpRevision](t, rec)
+	if len(revisions) != 1 || revisions[0].Body != "I am the one who knocks." {
+		t.Errorf("expected the original body as the only revision, got %s", rec.Body)
+	}
+}
+
+func TestEditChirp_Window(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	rec := ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "I am the one who knocks."})
+	expectStatus(t, rec, 201)
+	chirp := decodeResponse[Chirp](t, rec)
+	path := "/api/chirps/" + chirp.ID.String()
+
+	ts.backdateChirp(chirp.ID, ts.cfg.chirpEdits.standard-time.Minute)
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 200)
+
+	ts.backdateChirp(chirp.ID, ts.cfg.chirpEdits.standard+time.Minute)
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Heisenberg."})
+	expectStatus(t, rec, 403)
+
+	// Chirpy Red members get a longer window.
+	_, err := ts.cfg.db.UpgradeUser(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("cannot upgrade user: %v", err)
+	}
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Heisenberg."})
+	expectStatus(t, rec, 200)
+
+	ts.backdateChirp(chirp.ID, ts.cfg.chirpEdits.chirpyRed+time.Minute)
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Tread lightly."})
+	expectStatus(t, rec, 403)
+}
+
+func TestEditChirp_RequiresVerifiedEmail(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	rec := ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "I am the one who knocks."})
+	expectStatus(t, rec, 201)
+	chirp := decodeResponse[Chirp](t, rec)
+	path := "/api/chirps/" + chirp.ID.String()
+
+	ts.cfg.requireVerifiedEmail = true
+	rec = ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 403)
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 403)
+
+	_, err := ts.cfg.db.VerifyUserEmail(context.Background(), database.VerifyUserEmailParams{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+	if err != nil {
+		t.Fatalf("cannot verify email: %v", err)
+	}
+	rec = ts.request("PATCH", path, user.Token, map[string]string{"body": "Say my name."})
+	expectStatus(t, rec, 200)
+}
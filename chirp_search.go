@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/chirps"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// ChirpSearchResult is a chirp matching a search, with how well it matched
+// and an HTML snippet of its body with the matching words in <mark> tags.
+type ChirpSearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// handleSearchChirps finds chirps matching q, best matches first. q takes
+// words, "quoted phrases", prefix* and -excluded terms, and can be combined
+// with author_id and a since/until date range (RFC 3339). Pages work like
+// GET /api/chirps: pass next_cursor back as cursor.
+func (cfg *apiConfig) handleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	tsquery, err := chirps.ParseSearchQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(w, 400, "search query is required")
+		return
+	}
+
+	params := database.SearchChirpsParams{
+		HeadlineOptions: chirps.HeadlineOptions,
+		Query:           tsquery,
+		Limit:           defaultChirpPageSize,
+	}
+
+	if value := query.Get("author_id"); value != "" {
+		authorID, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, 400, "invalid author id")
+			return
+		}
+		params.AuthorID = nullUUID(authorID)
+	}
+
+	for _, bound := range []struct {
+		name  string
+		param *sql.NullTime
+	}{{"since", &params.Since}, {"until", &params.Until}} {
+		name, param := bound.name, bound.param
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondWithError(w, 400, "invalid "+name+" date")
+			return
+		}
+		*param = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	if params.Since.Valid && params.Until.Valid && !params.Since.Time.Before(params.Until.Time) {
+		respondWithError(w, 400, "since must be before until")
+		return
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := chirps.DecodeCursor(value)
+		if err != nil {
+			respondWithError(w, 400, "invalid cursor")
+			return
+		}
+		params.AfterRank = sql.NullFloat64{Float64: float64(cursor.Rank), Valid: true}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = nullUUID(cursor.ID)
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxChirpPageSize {
+			respondWithError(w, 400, "invalid limit")
+			return
+		}
+		params.Limit = int32(limit)
+	}
+
+	// One result more than the page holds tells whether there is a next page.
+	pageSize := params.Limit
+	params.Limit++
+	rows, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "cannot search chirps")
+		return
+	}
+
+	type searchPage struct {
+		Results    []ChirpSearchResult `json:"results"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+	}
+
+	page := searchPage{Results: []ChirpSearchResult{}}
+	for i, row := range rows {
+		if i == int(pageSize) {
+			last := rows[i-1]
+			page.NextCursor = chirps.EncodeCursor(chirps.Cursor{Rank: last.Rank, CreatedAt: last.CreatedAt, ID: last.ID})
+			break
+		}
+		page.Results = append(page.Results, ChirpSearchResult{
+			Chirp: Chirp{
+				ID:        row.ID,
+				Body:      row.Body,
+				UserID:    row.UserID,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				Edited:    row.EditedAt.Valid,
+			},
+			Rank:    row.Rank,
+			Snippet: chirps.RenderHeadline(row.Snippet),
+		})
+	}
+
+	if page.NextCursor != "" {
+		query.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", "<"+cfg.baseURL+r.URL.Path+"?"+query.Encode()+`>; rel="next"`)
+	}
+	respondWithJson(w, 200, page)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+const usage = `usage:
+  chirpy                          start the server
+  chirpy promote <email> [role]   set a user's role (default admin)
+  chirpy purge-deleted-users      delete accounts past their deletion grace period`
+
+// runCommand runs an administrative subcommand instead of the server. It is
+// how the first admin is bootstrapped, before anyone can call /admin/*.
+func runCommand(ctx context.Context, db *database.Queries, args []string) error {
+	switch args[0] {
+	case "promote":
+		if len(args) < 2 || len(args) > 3 {
+			return errors.New(usage)
+		}
+		role := auth.RoleAdmin
+		if len(args) == 3 {
+			role = args[2]
+		}
+		if !auth.ValidRole(role) {
+			return fmt.Errorf("unknown role %q", role)
+		}
+
+		user, err := db.GetUserByEmail(ctx, args[1])
+		if err != nil {
+			return fmt.Errorf("cannot promote %s: %w", args[1], err)
+		}
+		oldRole := user.Role
+
+		user, err = db.SetUserRoleByEmail(ctx, database.SetUserRoleByEmailParams{
+			Email: args[1],
+			Role:  role,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot promote %s: %w", args[1], err)
+		}
+
+		// There is no request to record an actor or address for; the
+		// metadata says the change was made from the command line.
+		metadata, err := json.Marshal(map[string]any{"old_role": oldRole, "new_role": user.Role, "source": "cli"})
+		if err != nil {
+			return err
+		}
+		err = db.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+			EventType: auditRoleChanged,
+			UserID:    nullUUID(user.ID),
+			Metadata:  metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot audit promotion of %s: %w", args[1], err)
+		}
+
+		fmt.Printf("%s is now %s\n", user.Email, user.Role)
+		return nil
+	case "purge-deleted-users":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		purged, err := purgeDeletedAccounts(ctx, db)
+		if err != nil {
+			return fmt.Errorf("cannot purge deleted users: %w", err)
+		}
+		fmt.Printf("purged %d accounts\n", purged)
+		return nil
+	default:
+		return errors.New(usage)
+	}
+}
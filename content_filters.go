@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sidis405/chirpy/internal/chirps"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+const defaultFilterHitLimit = 50
+const maxFilterHitLimit = 500
+
+// ContentFilter is a rule chirps are checked against, with how often it fired.
+type ContentFilter struct {
+	ID        uuid.UUID  `json:"id"`
+	Kind      string     `json:"kind"`
+	Pattern   string     `json:"pattern"`
+	Action    string     `json:"action"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	HitCount  int64      `json:"hit_count"`
+	LastHitAt *time.Time `json:"last_hit_at"`
+}
+
+// ContentFilterHit records a content filter matching a chirp. ChirpID is nil
+// when the chirp was rejected.
+type ContentFilterHit struct {
+	ID          uuid.UUID  `json:"id"`
+	FilterID    uuid.UUID  `json:"filter_id"`
+	Kind        string     `json:"kind"`
+	Pattern     string     `json:"pattern"`
+	Action      string     `json:"action"`
+	ChirpID     *uuid.UUID `json:"chirp_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	MatchedText string     `json:"matched_text"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (cfg *apiConfig) handleListContentFilters(w http.ResponseWriter, r *http.Request) {
+	dbFilters, err := cfg.db.ListContentFilters(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch filters")
+		return
+	}
+	stats, err := cfg.db.ListContentFilterStats(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch filters")
+		return
+	}
+
+	statsByFilter := map[uuid.UUID]database.ListContentFilterStatsRow{}
+	for _, stat := range stats {
+		statsByFilter[stat.FilterID] = stat
+	}
+
+	filters := []ContentFilter{}
+	for _, dbFilter := range dbFilters {
+		filter := dbContentFilterToContentFilterStruct(dbFilter)
+		if stat, ok := statsByFilter[dbFilter.ID]; ok {
+			filter.HitCount = stat.HitCount
+			filter.LastHitAt = &stat.LastHitAt
+		}
+		filters = append(filters, filter)
+	}
+
+	respondWithJson(w, 200, filters)
+}
+
+func (cfg *apiConfig) handleGetContentFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid filter id")
+		return
+	}
+
+	filter, err := cfg.db.GetContentFilter(r.Context(), filterID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch filter")
+		return
+	}
+
+	respondWithJson(w, 200, dbContentFilterToContentFilterStruct(filter))
+}
+
+func (cfg *apiConfig) handleCreateContentFilter(w http.ResponseWriter, r *http.Request) {
+	actor := claimsFromContext(r.Context())
+
+	rule, ok := decodeContentFilter(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := cfg.db.CreateContentFilter(r.Context(), database.CreateContentFilterParams{
+		Kind:      rule.Kind,
+		Pattern:   rule.Pattern,
+		Action:    rule.Action,
+		CreatedBy: nullUUID(actor.UserID),
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		respondWithError(w, 409, "filter already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot create filter")
+		return
+	}
+
+	cfg.contentFiltersChanged(r, auditContentFilterCreated, filter)
+	respondWithJson(w, 201, dbContentFilterToContentFilterStruct(filter))
+}
+
+func (cfg *apiConfig) handleUpdateContentFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid filter id")
+		return
+	}
+
+	rule, ok := decodeContentFilter(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := cfg.db.UpdateContentFilter(r.Context(), database.UpdateContentFilterParams{
+		ID:      filterID,
+		Kind:    rule.Kind,
+		Pattern: rule.Pattern,
+		Action:  rule.Action,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		respondWithError(w, 409, "filter already exists")
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot update filter")
+		return
+	}
+
+	cfg.contentFiltersChanged(r, auditContentFilterUpdated, filter)
+	respondWithJson(w, 200, dbContentFilterToContentFilterStruct(filter))
+}
+
+func (cfg *apiConfig) handleDeleteContentFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid filter id")
+		return
+	}
+
+	filter, err := cfg.db.GetContentFilter(r.Context(), filterID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot delete filter")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteContentFilter(r.Context(), filterID)
+	if err != nil {
+		respondWithError(w, 500, "cannot delete filter")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	cfg.contentFiltersChanged(r, auditContentFilterDeleted, filter)
+	respondWithJson(w, 204, nil)
+}
+
+// handleListContentFilterHits lists the most recent filter hits, filtered by
+// the filter_id, action and since query parameters.
+func (cfg *apiConfig) handleListContentFilterHits(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := database.ListContentFilterHitsParams{Limit: defaultFilterHitLimit}
+
+	if value := query.Get("filter_id"); value != "" {
+		filterID, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, 400, "invalid filter_id")
+			return
+		}
+		params.FilterID = nullUUID(filterID)
+	}
+	if action := query.Get("action"); action != "" {
+		params.Action = sql.NullString{String: action, Valid: true}
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondWithError(w, 400, "invalid since")
+			return
+		}
+		params.Since = sql.NullTime{Time: since.UTC(), Valid: true}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxFilterHitLimit {
+			respondWithError(w, 400, "invalid limit")
+			return
+		}
+		params.Limit = int32(limit)
+	}
+
+	dbHits, err := cfg.db.ListContentFilterHits(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch filter hits")
+		return
+	}
+
+	hits := []ContentFilterHit{}
+	for _, dbHit := range dbHits {
+		hit := ContentFilterHit{
+			ID:          dbHit.ID,
+			FilterID:    dbHit.FilterID,
+			Kind:        dbHit.Kind,
+			Pattern:     dbHit.Pattern,
+			Action:      dbHit.Action,
+			UserID:      dbHit.UserID,
+			MatchedText: dbHit.MatchedText,
+			CreatedAt:   dbHit.CreatedAt,
+		}
+		if dbHit.ChirpID.Valid {
+			hit.ChirpID = &dbHit.ChirpID.UUID
+		}
+		hits = append(hits, hit)
+	}
+
+	respondWithJson(w, 200, hits)
+}
+
+// decodeContentFilter reads a filter rule from the request body and rejects
+// rules that could not be compiled.
+func decodeContentFilter(w http.ResponseWriter, r *http.Request) (chirps.Rule, bool) {
+	type parameters struct {
+		Kind    string `json:"kind"`
+		Pattern string `json:"pattern"`
+		Action  string `json:"action"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return chirps.Rule{}, false
+	}
+
+	rule := chirps.Rule{Kind: params.Kind, Pattern: params.Pattern, Action: params.Action}
+	err = chirps.ValidateRule(rule)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return chirps.Rule{}, false
+	}
+	return rule, true
+}
+
+// contentFiltersChanged audits a change to filter and applies the new rules
+// straight away. Other instances pick them up on their next reload.
+func (cfg *apiConfig) contentFiltersChanged(r *http.Request, eventType string, filter database.ContentFilter) {
+	actor := claimsFromContext(r.Context())
+	cfg.audit(r, auditEvent{
+		Type:    eventType,
+		ActorID: actor.UserID,
+		Metadata: map[string]any{
+			"filter_id": filter.ID,
+			"kind":      filter.Kind,
+			"pattern":   filter.Pattern,
+			"action":    filter.Action,
+		},
+	})
+
+	err := cfg.reloadContentFilters(r.Context())
+	if err != nil {
+		log.Printf("Error reloading content filters: %s", err)
+	}
+}
+
+// reloadContentFilters compiles the stored filters into a new matcher for the
+// chirp policy. Chirps already being checked finish with the old one.
+func (cfg *apiConfig) reloadContentFilters(ctx context.Context) error {
+	dbFilters, err := cfg.db.ListContentFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	var rules []chirps.Rule
+	for _, filter := range dbFilters {
+		rule := chirps.Rule{
+			ID:      filter.ID.String(),
+			Kind:    filter.Kind,
+			Pattern: filter.Pattern,
+			Action:  filter.Action,
+		}
+		err = chirps.ValidateRule(rule)
+		if err != nil {
+			log.Printf("Skipping content filter %s: %s", filter.ID, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	matcher, err := chirps.NewMatcher(rules)
+	if err != nil {
+		return err
+	}
+	cfg.chirpPolicy.SetMatcher(matcher)
+	return nil
+}
+
+// runContentFilterReload reloads the content filters every interval until ctx
+// is done, so changes made through another instance are picked up.
+func (cfg *apiConfig) runContentFilterReload(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := cfg.reloadContentFilters(ctx)
+			if err != nil {
+				log.Printf("Error reloading content filters: %s", err)
+			}
+		}
+	}
+}
+
+// recordFilterHits stores the filter hits of a chirp posted by userID.
+// chirpID is uuid.Nil when the chirp was rejected.
+func (cfg *apiConfig) recordFilterHits(ctx context.Context, userID, chirpID uuid.UUID, hits []chirps.Hit) {
+	for _, hit := range hits {
+		filterID, err := uuid.Parse(hit.Rule.ID)
+		if err != nil {
+			continue
+		}
+		err = cfg.db.CreateContentFilterHit(ctx, database.CreateContentFilterHitParams{
+			FilterID:    filterID,
+			ChirpID:     nullUUID(chirpID),
+			UserID:      userID,
+			Action:      hit.Rule.Action,
+			MatchedText: hit.Text,
+		})
+		if err != nil {
+			log.Printf("Error recording hit of content filter %s: %s", filterID, err)
+		}
+	}
+}
+
+func dbContentFilterToContentFilterStruct(filter database.ContentFilter) ContentFilter {
+	contentFilter := ContentFilter{
+		ID:        filter.ID,
+		Kind:      filter.Kind,
+		Pattern:   filter.Pattern,
+		Action:    filter.Action,
+		CreatedAt: filter.CreatedAt,
+		UpdatedAt: filter.UpdatedAt,
+	}
+	if filter.CreatedBy.Valid {
+		contentFilter.CreatedBy = &filter.CreatedBy.UUID
+	}
+	return contentFilter
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/mailer"
+)
+
+const dataExportReady = "ready"
+
+// dataExportStaleAfter is how long an export may stay processing before it is
+// assumed its worker died and the export is queued again.
+const dataExportStaleAfter = 15 * time.Minute
+const dataExportSweepInterval = time.Minute
+
+// DataExport is a request for a copy of everything Chirpy stores about a user.
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	SizeBytes   *int64     `json:"size_bytes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// LinkedIdentity is an external account a user logs in with.
+type LinkedIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handleRequestDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	// Only one export is built per user at a time; asking again while one is
+	// queued returns that one.
+	export, err := cfg.db.GetActiveDataExportForUser(r.Context(), userID)
+	if err == nil {
+		respondWithJson(w, 202, dbDataExportToDataExportStruct(export))
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "cannot request export")
+		return
+	}
+
+	export, err = cfg.db.CreateDataExport(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot request export")
+		return
+	}
+
+	select {
+	case cfg.exportQueued <- struct{}{}:
+	default:
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditDataExportRequested,
+		UserID:   userID,
+		Metadata: map[string]any{"export_id": export.ID},
+	})
+	respondWithJson(w, 202, dbDataExportToDataExportStruct(export))
+}
+
+// handleGetDataExport reports the status of an export, and once it is ready
+// responds with the archive itself.
+func (cfg *apiConfig) handleGetDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	exportID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid export id")
+		return
+	}
+
+	export, err := cfg.db.GetDataExport(r.Context(), database.GetDataExportParams{
+		ID:     exportID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch export")
+		return
+	}
+
+	if export.Status != dataExportReady {
+		respondWithJson(w, 200, dbDataExportToDataExportStruct(export))
+		return
+	}
+	if export.ExpiresAt.Time.Before(time.Now()) {
+		respondWithError(w, 410, "export has expired")
+		return
+	}
+
+	file, err := os.Open(cfg.dataExportPath(export.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		respondWithError(w, 410, "export has expired")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot open export")
+		return
+	}
+	defer file.Close()
+
+	cfg.audit(r, auditEvent{
+		Type:     auditDataExportDownloaded,
+		UserID:   userID,
+		Metadata: map[string]any{"export_id": export.ID},
+	})
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-export-%s.zip"`, export.CompletedAt.Time.Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, "", export.CompletedAt.Time, file)
+}
+
+// runDataExports builds queued exports as they come in and removes expired
+// ones, until ctx is done.
+func (cfg *apiConfig) runDataExports(ctx context.Context) {
+	ticker := time.NewTicker(dataExportSweepInterval)
+	defer ticker.Stop()
+
+	cfg.sweepDataExports(ctx)
+	for {
+		for cfg.processNextDataExport(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-cfg.exportQueued:
+		case <-ticker.C:
+			cfg.sweepDataExports(ctx)
+		}
+	}
+}
+
+// processNextDataExport builds the oldest queued export. It reports whether
+// there was one, so the caller knows to look for more.
+func (cfg *apiConfig) processNextDataExport(ctx context.Context) bool {
+	export, err := cfg.db.ClaimDataExport(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error claiming data export: %s", err)
+		return false
+	}
+
+	size, err := cfg.buildDataExport(ctx, export.UserID, cfg.dataExportPath(export.ID))
+	if err != nil {
+		log.Printf("Error building data export %s: %s", export.ID, err)
+		err = cfg.db.FailDataExport(ctx, database.FailDataExportParams{
+			ID:    export.ID,
+			Error: sql.NullString{String: "the export could not be built, please request a new one", Valid: true},
+		})
+		if err != nil {
+			log.Printf("Error marking data export %s as failed: %s", export.ID, err)
+		}
+		return true
+	}
+
+	expiresAt := time.Now().Add(cfg.exportTTL)
+	err = cfg.db.CompleteDataExport(ctx, database.CompleteDataExportParams{
+		ID:        export.ID,
+		SizeBytes: sql.NullInt64{Int64: size, Valid: true},
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error completing data export %s: %s", export.ID, err)
+		return true
+	}
+
+	user, err := cfg.db.GetUser(ctx, export.UserID)
+	if err != nil {
+		return true
+	}
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy data export is ready",
+		Body: fmt.Sprintf(
+			"The copy of your Chirpy data you asked for is ready. Download it from:\n\n%s/api/me/export/%s\n\n"+
+				"It will be deleted on %s.",
+			cfg.baseURL, export.ID, expiresAt.Format("January 2, 2006"),
+		),
+	})
+	return true
+}
+
+// buildDataExport writes a ZIP archive of everything stored about userID to
+// path and returns its size. The archive is written next to path and renamed
+// into place, so a half-written file is never served.
+func (cfg *apiConfig) buildDataExport(ctx context.Context, userID uuid.UUID, path string) (int64, error) {
+	files, err := cfg.collectDataExport(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	archive := zip.NewWriter(tmp)
+	for _, file := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return 0, err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.data)
+		if err != nil {
+			return 0, fmt.Errorf("encoding %s: %w", file.name, err)
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return 0, err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+type dataExportFile struct {
+	name string
+	data any
+}
+
+// collectDataExport gathers the contents of a user's export, one JSON file
+// per kind of data.
+func (cfg *apiConfig) collectDataExport(ctx context.Context, userID uuid.UUID) ([]dataExportFile, error) {
+	user, err := cfg.db.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	totp, err := cfg.db.GetTOTP(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	type profile struct {
+		User
+		TwoFactorEnabled bool `json:"two_factor_enabled"`
+	}
+	userProfile := profile{
+		User:             dbUserToUserStruct(user),
+		TwoFactorEnabled: err == nil && totp.ConfirmedAt.Valid,
+	}
+
+	dbChirps, err := cfg.db.GetAllChirpsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	chirps := []Chirp{}
+	for _, chirp := range dbChirps {
+		chirps = append(chirps, dbChirpToChirpStruct(chirp))
+	}
+
+	refreshTokens, err := cfg.db.ListSessionsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []Session{}
+	for _, refreshToken := range refreshTokens {
+		sessions = append(sessions, dbRefreshTokenToSessionStruct(refreshToken))
+	}
+
+	dbTokens, err := cfg.db.ListPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []PersonalAccessToken{}
+	for _, pat := range dbTokens {
+		tokens = append(tokens, dbTokenToTokenStruct(pat))
+	}
+
+	grants, err := cfg.db.ListOAuthGrantsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	apps := []AuthorizedApp{}
+	for _, grant := range grants {
+		apps = append(apps, AuthorizedApp{
+			ClientID:  grant.ClientID,
+			Name:      grant.ClientName,
+			Scopes:    grant.Scopes,
+			CreatedAt: grant.CreatedAt,
+			UpdatedAt: grant.UpdatedAt,
+		})
+	}
+
+	dbClients, err := cfg.db.ListOAuthClientsForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	clients := []OAuthClient{}
+	for _, client := range dbClients {
+		clients = append(clients, dbOAuthClientToOAuthClientStruct(client))
+	}
+
+	dbRevisions, err := cfg.db.ListChirpRevisionsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	revisions := []ChirpRevision{}
+	for _, revision := range dbRevisions {
+		revisions = append(revisions, dbChirpRevisionToChirpRevisionStruct(revision))
+	}
+
+	dbIdentities, err := cfg.db.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	identities := []LinkedIdentity{}
+	for _, identity := range dbIdentities {
+		identities = append(identities, LinkedIdentity{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	dbEvents, err := cfg.db.ListAuditEventsForUser(ctx, nullUUID(userID))
+	if err != nil {
+		return nil, err
+	}
+	events := []AuditEvent{}
+	for _, event := range dbEvents {
+		events = append(events, dbAuditEventToAuditEventStruct(event))
+	}
+
+	return []dataExportFile{
+		{"profile.json", userProfile},
+		{"chirps.json", chirps},
+		{"chirp_revisions.json", revisions},
+		{"sessions.json", sessions},
+		{"personal_access_tokens.json", tokens},
+		{"authorized_apps.json", apps},
+		{"oauth_clients.json", clients},
+		{"linked_identities.json", identities},
+		{"security_events.json", events},
+	}, nil
+}
+
+// sweepDataExports requeues exports whose worker died, forgets expired ones
+// and deletes archives that outlived their export, including those of
+// accounts that have since been purged.
+func (cfg *apiConfig) sweepDataExports(ctx context.Context) {
+	err := cfg.db.RequeueStaleDataExports(ctx, time.Now().Add(-dataExportStaleAfter))
+	if err != nil {
+		log.Printf("Error requeueing stale data exports: %s", err)
+	}
+
+	err = cfg.db.DeleteExpiredDataExports(ctx)
+	if err != nil {
+		log.Printf("Error deleting expired data exports: %s", err)
+	}
+
+	entries, err := os.ReadDir(cfg.exportDir)
+	if err != nil {
+		log.Printf("Error reading DATA_EXPORT_DIR: %s", err)
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !strings.HasSuffix(entry.Name(), ".zip") && !strings.HasPrefix(entry.Name(), ".export-") {
+			continue
+		}
+		if time.Since(info.ModTime()) < cfg.exportTTL {
+			continue
+		}
+		err = os.Remove(filepath.Join(cfg.exportDir, entry.Name()))
+		if err != nil {
+			log.Printf("Error removing expired data export %s: %s", entry.Name(), err)
+		}
+	}
+}
+
+func (cfg *apiConfig) dataExportPath(id uuid.UUID) string {
+	return filepath.Join(cfg.exportDir, id.String()+".zip")
+}
+
+func dbDataExportToDataExportStruct(export database.DataExport) DataExport {
+	dataExport := DataExport{
+		ID:        export.ID,
+		Status:    export.Status,
+		Error:     export.Error.String,
+		CreatedAt: export.CreatedAt,
+	}
+	if export.SizeBytes.Valid {
+		dataExport.SizeBytes = &export.SizeBytes.Int64
+	}
+	if export.CompletedAt.Valid {
+		dataExport.CompletedAt = &export.CompletedAt.Time
+	}
+	if export.ExpiresAt.Valid {
+		dataExport.ExpiresAt = &export.ExpiresAt.Time
+	}
+	return dataExport
+}
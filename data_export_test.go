@@ -0,0 +1,104 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDataExport(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	rec := ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "I am the one who knocks."})
+	expectStatus(t, rec, 201)
+
+	rec = ts.request("POST", "/api/me/export", user.Token, nil)
+	expectStatus(t, rec, 202)
+	export := decodeResponse[DataExport](t, rec)
+
+	// Asking again while one is queued returns the same export.
+	rec = ts.request("POST", "/api/me/export", user.Token, nil)
+	expectStatus(t, rec, 202)
+	if again := decodeResponse[DataExport](t, rec); again.ID != export.ID {
+		t.Errorf("expected export %s again, got %s", export.ID, again.ID)
+	}
+
+	rec = ts.request("GET", "/api/me/export/"+export.ID.String(), user.Token, nil)
+	expectStatus(t, rec, 200)
+	if pending := decodeResponse[DataExport](t, rec); pending.Status == dataExportReady {
+		t.Fatalf("expected the export to wait for the worker")
+	}
+
+	if !ts.cfg.processNextDataExport(context.Background()) {
+		t.Fatalf("expected the worker to find the export")
+	}
+	if ts.cfg.processNextDataExport(context.Background()) {
+		t.Errorf("expected no more exports to build")
+	}
+
+	rec = ts.request("GET", "/api/me/export/"+export.ID.String(), user.Token, nil)
+	expectStatus(t, rec, 200)
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/zip" {
+		t.Fatalf("expected a zip, got %q: %s", contentType, rec.Body)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("cannot read zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, file := range archive.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatalf("cannot open %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("cannot read %s: %v", file.Name, err)
+		}
+		files[file.Name] = string(data)
+	}
+	if !strings.Contains(files["profile.json"], "walt@breakingbad.com") {
+		t.Errorf("expected the profile in the export, got %q", files["profile.json"])
+	}
+	if !strings.Contains(files["chirps.json"], "I am the one who knocks.") {
+		t.Errorf("expected the chirps in the export, got %q", files["chirps.json"])
+	}
+}
+
+func TestDataExport_OnlyForOwner(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	other := ts.createUser("jesse@breakingbad.com")
+
+	rec := ts.request("POST", "/api/me/export", user.Token, nil)
+	expectStatus(t, rec, 202)
+	export := decodeResponse[DataExport](t, rec)
+	ts.cfg.processNextDataExport(context.Background())
+
+	rec = ts.request("GET", "/api/me/export/"+export.ID.String(), other.Token, nil)
+	expectStatus(t, rec, 404)
+}
+
+func TestDataExport_NotWhileImpersonating(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.createAdmin("gus@lospollos.com")
+	user := ts.createUser("walt@breakingbad.com")
+
+	rec := ts.request("POST", "/api/me/export", user.Token, nil)
+	expectStatus(t, rec, 202)
+	export := decodeResponse[DataExport](t, rec)
+	ts.cfg.processNextDataExport(context.Background())
+
+	// Not even a read-only look at the user's account reaches their archive.
+	for _, allowWrites := range []bool{false, true} {
+		impersonation := ts.impersonate(admin, user, allowWrites)
+		rec = ts.request("GET", "/api/me/export/"+export.ID.String(), impersonation.Token, nil)
+		expectStatus(t, rec, 403)
+		rec = ts.request("POST", "/api/me/export", impersonation.Token, nil)
+		expectStatus(t, rec, 403)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/mailer"
+)
+
+const emailVerificationTokenDuration = 24 * time.Hour
+
+// sendVerificationEmail mails a link proving the user owns email. For an
+// email change the link goes to the new address, which only becomes the
+// login identity once the link is followed.
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.db.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(emailVerificationTokenDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	cfg.sendMail(mailer.Message{
+		To:      email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf(
+			"Confirm this address for your Chirpy account by opening:\n\n%s/api/verify-email?token=%s\n\n"+
+				"The link expires in %d hours.",
+			cfg.baseURL, url.QueryEscape(token), int(emailVerificationTokenDuration.Hours()),
+		),
+	})
+	return nil
+}
+
+func (cfg *apiConfig) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, 400, "missing token")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot verify email")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	verificationToken, err := qtx.ConsumeEmailVerificationToken(r.Context(), auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "invalid or expired token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot verify email")
+		return
+	}
+
+	user, err := qtx.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		ID:    verificationToken.UserID,
+		Email: verificationToken.Email,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		respondWithError(w, 409, "email already in use")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot verify email")
+		return
+	}
+
+	err = qtx.InvalidateEmailVerificationTokensForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, "cannot verify email")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot verify email")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditEmailVerified,
+		UserID:   user.ID,
+		Metadata: map[string]any{"email": user.Email},
+	})
+	respondWithJson(w, 200, dbUserToUserStruct(user))
+}
+
+func (cfg *apiConfig) handleResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r, auth.ScopeAccountWrite)
+	if !ok {
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, 409, "email address already verified")
+		return
+	}
+
+	err = cfg.sendVerificationEmail(r.Context(), user.ID, user.Email)
+	if err != nil {
+		respondWithError(w, 500, "cannot send verification email")
+		return
+	}
+
+	respondWithJson(w, 202, nil)
+}
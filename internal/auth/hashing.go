@@ -1,23 +1,134 @@
 package auth
 
 import (
-	"runtime"
+	"errors"
+	"strings"
 
 	"github.com/alexedwards/argon2id"
+	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	params := &argon2id.Params{
-		Memory:      128 * 1024,
-		Iterations:  4,
-		Parallelism: uint8(runtime.NumCPU()),
-		SaltLength:  16,
-		KeyLength:   32,
-	}
+// ErrUnknownHashFormat is returned for stored hashes no configured Hasher
+// recognizes, such as accounts that never had a password set.
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// Hasher is one password hashing algorithm. Hashes are self-describing
+// (PHC or modular crypt strings) so several algorithms can share a column.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Recognizes reports whether hash was produced by this algorithm.
+	Recognizes(hash string) bool
+	Verify(password, hash string) (bool, error)
+	// NeedsRehash reports whether hash was produced with other parameters
+	// than the ones this Hasher is configured with.
+	NeedsRehash(hash string) bool
+}
+
+// DefaultArgon2idParams are fixed rather than derived from the host, so the
+// same password hashes the same way on every instance.
+var DefaultArgon2idParams = argon2id.Params{
+	Memory:      128 * 1024,
+	Iterations:  4,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
 
-	return argon2id.CreateHash(password, params)
+// Argon2idHasher hashes with argon2id. It is the default algorithm.
+type Argon2idHasher struct {
+	Params argon2id.Params
 }
 
-func CheckPasswordHash(password string, hash string) (bool, error) {
+func NewArgon2idHasher(params argon2id.Params) *Argon2idHasher {
+	return &Argon2idHasher{Params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	return argon2id.CreateHash(password, &h.Params)
+}
+
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Verify(password, hash string) (bool, error) {
 	return argon2id.ComparePasswordAndHash(password, hash)
 }
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := argon2id.DecodeHash(hash)
+	if err != nil {
+		return true
+	}
+	return *params != h.Params
+}
+
+// DefaultBcryptCost is the bcrypt cost used unless configured otherwise.
+const DefaultBcryptCost = bcrypt.DefaultCost
+
+// BcryptHasher hashes with bcrypt, mostly so users imported from other
+// systems can keep their passwords.
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) Verify(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// PasswordHasher hashes new passwords with its preferred Hasher and verifies
+// hashes made by any of its Hashers.
+type PasswordHasher struct {
+	preferred Hasher
+	hashers   []Hasher
+}
+
+func NewPasswordHasher(preferred Hasher, others ...Hasher) *PasswordHasher {
+	return &PasswordHasher{
+		preferred: preferred,
+		hashers:   append([]Hasher{preferred}, others...),
+	}
+}
+
+func (p *PasswordHasher) Hash(password string) (string, error) {
+	return p.preferred.Hash(password)
+}
+
+// Verify checks password against hash. When it matches, needsRehash reports
+// whether hash should be replaced because it uses another algorithm or
+// outdated parameters.
+func (p *PasswordHasher) Verify(password, hash string) (match bool, needsRehash bool, err error) {
+	for _, hasher := range p.hashers {
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+		match, err = hasher.Verify(password, hash)
+		if err != nil || !match {
+			return false, false, err
+		}
+		return true, hasher != p.preferred || p.preferred.NeedsRehash(hash), nil
+	}
+	return false, false, ErrUnknownHashFormat
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alexedwards/argon2id"
+)
+
+var testArgon2idParams = argon2id.Params{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	hasher := NewPasswordHasher(NewArgon2idHasher(testArgon2idParams), NewBcryptHasher(4))
+
+	hash, err := hasher.Hash("04234")
+	if err != nil {
+		t.Fatalf("unexpected error hashing: %v", err)
+	}
+
+	match, needsRehash, err := hasher.Verify("04234", hash)
+	if err != nil || !match || needsRehash {
+		t.Errorf("expected fresh hash to match without rehash, got match=%v rehash=%v err=%v", match, needsRehash, err)
+	}
+
+	match, _, err = hasher.Verify("wrong", hash)
+	if err != nil || match {
+		t.Errorf("expected wrong password not to match, got match=%v err=%v", match, err)
+	}
+}
+
+func TestPasswordHasher_RehashesOutdatedParams(t *testing.T) {
+	old := NewArgon2idHasher(testArgon2idParams)
+	hash, err := old.Hash("04234")
+	if err != nil {
+		t.Fatalf("unexpected error hashing: %v", err)
+	}
+
+	params := testArgon2idParams
+	params.Iterations = 2
+	hasher := NewPasswordHasher(NewArgon2idHasher(params))
+
+	match, needsRehash, err := hasher.Verify("04234", hash)
+	if err != nil || !match || !needsRehash {
+		t.Errorf("expected outdated hash to match and need rehash, got match=%v rehash=%v err=%v", match, needsRehash, err)
+	}
+}
+
+func TestPasswordHasher_ImportedBcrypt(t *testing.T) {
+	hash, err := NewBcryptHasher(4).Hash("04234")
+	if err != nil {
+		t.Fatalf("unexpected error hashing: %v", err)
+	}
+
+	hasher := NewPasswordHasher(NewArgon2idHasher(testArgon2idParams), NewBcryptHasher(4))
+	match, needsRehash, err := hasher.Verify("04234", hash)
+	if err != nil || !match || !needsRehash {
+		t.Errorf("expected bcrypt hash to match and need rehash, got match=%v rehash=%v err=%v", match, needsRehash, err)
+	}
+
+	match, _, err = hasher.Verify("wrong", hash)
+	if err != nil || match {
+		t.Errorf("expected wrong password not to match, got match=%v err=%v", match, err)
+	}
+}
+
+func TestPasswordHasher_UnknownFormat(t *testing.T) {
+	hasher := NewPasswordHasher(NewArgon2idHasher(testArgon2idParams))
+
+	_, _, err := hasher.Verify("unset", "unset")
+	if !errors.Is(err, ErrUnknownHashFormat) {
+		t.Errorf("expected ErrUnknownHashFormat, got %v", err)
+	}
+}
@@ -1,47 +1,150 @@
 package auth
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-const tokenDuration = time.Duration(3600) * time.Second
+// tokenUseMFA marks the short-lived challenge tokens handed out between the
+// password and second-factor steps of a login. Access tokens carry no
+// token_use claim.
+const tokenUseMFA = "mfa"
 
-func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
-	signingKey := []byte(tokenSecret)
-	claims := &jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenDuration)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-	}
+// tokenUseOAuth marks access tokens issued to third-party OAuth clients, so
+// they are never mistaken for a user's own session.
+const tokenUseOAuth = "oauth"
+
+// Claims are the claims of every JWT Chirpy issues. UserID is the parsed
+// subject, filled in by validation.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role      string    `json:"role,omitempty"`
+	TokenUse  string    `json:"token_use,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	ClientID  string    `json:"client_id,omitempty"`
+	GrantID   string    `json:"grant_id,omitempty"`
+	SessionID string    `json:"sid,omitempty"`
+	Act       *Actor    `json:"act,omitempty"`
+	ReadOnly  bool      `json:"read_only,omitempty"`
+	UserID    uuid.UUID `json:"-"`
+}
+
+// Actor identifies who is really behind a token issued to act as another
+// user, as in the RFC 8693 act claim.
+type Actor struct {
+	Sub string `json:"sub"`
+}
+
+// Impersonated reports whether the token was issued to someone acting as the
+// subject rather than to the subject itself.
+func (c *Claims) Impersonated() bool {
+	return c.Act != nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	jwt, err := token.SignedString(signingKey)
-	return jwt, err
+// Scopes returns the space separated scope claim as a list.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		return []byte(tokenSecret), nil
+// MakeJWT signs an access token for userID with the HS256 tokenSecret.
+func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return NewHMACKeySet(tokenSecret).MakeJWT(userID, RoleUser, expiresIn)
+}
+
+// ValidateJWT validates an HS256 access token and returns its claims.
+func ValidateJWT(tokenString, tokenSecret string) (*Claims, error) {
+	return NewHMACKeySet(tokenSecret).ValidateJWT(tokenString)
+}
+
+// MakeJWT signs an access token for userID carrying role with the active key.
+func (ks *KeySet) MakeJWT(userID uuid.UUID, role string, expiresIn time.Duration) (string, error) {
+	return ks.makeToken(userID, expiresIn, Claims{Role: role})
+}
+
+// MakeSessionJWT signs an access token like MakeJWT, tied to the login
+// session (refresh token family) sessionID.
+func (ks *KeySet) MakeSessionJWT(userID uuid.UUID, role string, sessionID uuid.UUID, expiresIn time.Duration) (string, error) {
+	return ks.makeToken(userID, expiresIn, Claims{Role: role, SessionID: sessionID.String()})
+}
+
+// ValidateJWT validates an access token against the key named by its kid
+// header and returns its claims.
+func (ks *KeySet) ValidateJWT(tokenString string) (*Claims, error) {
+	return ks.validateToken(tokenString, "")
+}
+
+// MakeImpersonationToken signs an access token letting actorID act as
+// userID. With readOnly set the token may only be used for safe requests.
+func (ks *KeySet) MakeImpersonationToken(userID uuid.UUID, role string, actorID uuid.UUID, readOnly bool, expiresIn time.Duration) (string, error) {
+	return ks.makeToken(userID, expiresIn, Claims{
+		Role:     role,
+		Act:      &Actor{Sub: actorID.String()},
+		ReadOnly: readOnly,
 	})
+}
 
-	var userUuid uuid.UUID
+// MakeMFAToken signs the challenge token a user exchanges, together with a
+// second factor, for their access and refresh tokens.
+func (ks *KeySet) MakeMFAToken(userID uuid.UUID, expiresIn time.Duration) (string, error) {
+	return ks.makeToken(userID, expiresIn, Claims{TokenUse: tokenUseMFA})
+}
 
+// ValidateMFAToken validates a challenge token and returns its subject.
+func (ks *KeySet) ValidateMFAToken(tokenString string) (uuid.UUID, error) {
+	claims, err := ks.validateToken(tokenString, tokenUseMFA)
 	if err != nil {
-		return userUuid, err
+		return uuid.Nil, err
 	}
-	subject, err := token.Claims.GetSubject()
+	return claims.UserID, nil
+}
+
+// MakeOAuthAccessToken signs an access token for a third-party client acting
+// for userID under grantID. It only carries the scopes the user approved.
+func (ks *KeySet) MakeOAuthAccessToken(userID, clientID, grantID uuid.UUID, scopes []string, expiresIn time.Duration) (string, error) {
+	return ks.makeToken(userID, expiresIn, Claims{
+		TokenUse: tokenUseOAuth,
+		Scope:    strings.Join(scopes, " "),
+		ClientID: clientID.String(),
+		GrantID:  grantID.String(),
+	})
+}
+
+// ValidateOAuthAccessToken validates a third-party client's access token and
+// returns its claims.
+func (ks *KeySet) ValidateOAuthAccessToken(tokenString string) (*Claims, error) {
+	return ks.validateToken(tokenString, tokenUseOAuth)
+}
+
+// makeToken fills in the registered claims for userID and signs claims.
+func (ks *KeySet) makeToken(userID uuid.UUID, expiresIn time.Duration, claims Claims) (string, error) {
+	now := ks.now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		Subject:   userID.String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+
+	return ks.sign(&claims)
+}
+
+func (ks *KeySet) validateToken(tokenString, tokenUse string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, ks.keyFunc, jwt.WithTimeFunc(ks.now))
 	if err != nil {
-		return userUuid, err
+		return nil, err
+	}
+	if claims.TokenUse != tokenUse {
+		return nil, errors.New("unexpected token use")
 	}
-	userUuid, err = uuid.Parse(subject)
+	claims.UserID, err = uuid.Parse(claims.Subject)
 	if err != nil {
-		return userUuid, err
+		return nil, err
 	}
 
-	return userUuid, nil
+	return claims, nil
 }
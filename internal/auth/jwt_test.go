@@ -18,14 +18,17 @@ func TestMakeAndValidateJWT(t *testing.T) {
 		t.Fatalf("unexpected error creating JWT: %v", err)
 	}
 
-	validatedID, err := ValidateJWT(token, secret)
+	claims, err := ValidateJWT(token, secret)
 	if err != nil {
 		t.Fatalf("unexpected error validating JWT: %v", err)
 	}
 
 	// Assert
-	if validatedID != userID {
-		t.Errorf("expected %s, got %s", userID, validatedID)
+	if claims.UserID != userID {
+		t.Errorf("expected %s, got %s", userID, claims.UserID)
+	}
+	if claims.Impersonated() {
+		t.Error("expected a token without an actor")
 	}
 }
 
@@ -44,3 +47,119 @@ func TestValidateJWT_WrongSecret(t *testing.T) {
 		t.Error("expected error when validating with wrong secret, got nil")
 	}
 }
+
+func TestValidateJWT_RejectsMFAToken(t *testing.T) {
+	ks := NewHMACKeySet("testsecret")
+	userID := uuid.New()
+
+	mfaToken, err := ks.MakeMFAToken(userID, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating MFA token: %v", err)
+	}
+	if _, err := ks.ValidateJWT(mfaToken); err == nil {
+		t.Error("expected error using an MFA token as an access token, got nil")
+	}
+
+	validatedID, err := ks.ValidateMFAToken(mfaToken)
+	if err != nil {
+		t.Fatalf("unexpected error validating MFA token: %v", err)
+	}
+	if validatedID != userID {
+		t.Errorf("expected %s, got %s", userID, validatedID)
+	}
+
+	accessToken, err := ks.MakeJWT(userID, RoleUser, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating JWT: %v", err)
+	}
+	if _, err := ks.ValidateMFAToken(accessToken); err == nil {
+		t.Error("expected error using an access token as an MFA token, got nil")
+	}
+}
+
+func TestValidateJWT_CarriesRole(t *testing.T) {
+	ks := NewHMACKeySet("testsecret")
+
+	token, err := ks.MakeJWT(uuid.New(), RoleModerator, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating JWT: %v", err)
+	}
+
+	claims, err := ks.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating JWT: %v", err)
+	}
+	if claims.Role != RoleModerator {
+		t.Errorf("expected role %s, got %s", RoleModerator, claims.Role)
+	}
+	if !HasRole(claims.Role, RoleUser) || HasRole(claims.Role, RoleAdmin) {
+		t.Errorf("unexpected role hierarchy for %s", claims.Role)
+	}
+}
+
+func TestValidateJWT_CarriesSessionID(t *testing.T) {
+	ks := NewHMACKeySet("testsecret")
+	sessionID := uuid.New()
+
+	token, err := ks.MakeSessionJWT(uuid.New(), RoleUser, sessionID, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating JWT: %v", err)
+	}
+
+	claims, err := ks.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating JWT: %v", err)
+	}
+	if claims.SessionID != sessionID.String() {
+		t.Errorf("expected session %s, got %q", sessionID, claims.SessionID)
+	}
+}
+
+func TestOAuthAccessToken(t *testing.T) {
+	ks := NewHMACKeySet("testsecret")
+	userID, clientID, grantID := uuid.New(), uuid.New(), uuid.New()
+
+	token, err := ks.MakeOAuthAccessToken(userID, clientID, grantID, []string{ScopeChirpsWrite, ScopeAccountRead}, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating OAuth access token: %v", err)
+	}
+
+	if _, err := ks.ValidateJWT(token); err == nil {
+		t.Error("expected error using an OAuth access token as a session token, got nil")
+	}
+
+	claims, err := ks.ValidateOAuthAccessToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating OAuth access token: %v", err)
+	}
+	if claims.UserID != userID || claims.ClientID != clientID.String() || claims.GrantID != grantID.String() {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if scopes := claims.Scopes(); len(scopes) != 2 || scopes[0] != ScopeChirpsWrite || scopes[1] != ScopeAccountRead {
+		t.Errorf("unexpected scopes %v", scopes)
+	}
+}
+
+func TestImpersonationToken(t *testing.T) {
+	ks := NewHMACKeySet("testsecret")
+	userID, adminID := uuid.New(), uuid.New()
+
+	token, err := ks.MakeImpersonationToken(userID, RoleUser, adminID, true, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating impersonation token: %v", err)
+	}
+
+	claims, err := ks.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating impersonation token: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("expected subject %s, got %s", userID, claims.UserID)
+	}
+	if !claims.Impersonated() || claims.Act.Sub != adminID.String() {
+		t.Errorf("expected actor %s, got %+v", adminID, claims.Act)
+	}
+	if !claims.ReadOnly {
+		t.Error("expected a read-only token")
+	}
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one key of a KeySet. Keys loaded from disk are identified by
+// their kid, which is the file name without the .pem extension.
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   any
+	public    any
+	retiredAt time.Time
+}
+
+// KeySet holds the key used to sign new tokens and the keys still accepted
+// when validating them.
+type KeySet struct {
+	active         *signingKey
+	keys           map[string]*signingKey
+	rotationWindow time.Duration
+	now            func() time.Time
+}
+
+// NewHMACKeySet returns a KeySet signing with HS256 and a shared secret.
+// Tokens carry no kid header and the set publishes no JWKS keys.
+func NewHMACKeySet(secret string) *KeySet {
+	key := &signingKey{
+		method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+	return &KeySet{
+		active: key,
+		keys:   map[string]*signingKey{"": key},
+		now:    time.Now,
+	}
+}
+
+// LoadKeySet loads every RSA and Ed25519 private key (*.pem) in dir. The most
+// recently modified key signs new tokens. Every older key is retired when the
+// next one was added and stays valid for verification for rotationWindow
+// after that.
+func LoadKeySet(dir string, rotationWindow time.Duration) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+
+	type loadedKey struct {
+		key     *signingKey
+		modTime time.Time
+	}
+	var loaded []loadedKey
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := loadSigningKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", path, err)
+		}
+		loaded = append(loaded, loadedKey{key: key, modTime: info.ModTime()})
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].modTime.After(loaded[j].modTime)
+	})
+
+	ks := &KeySet{
+		active:         loaded[0].key,
+		keys:           map[string]*signingKey{},
+		rotationWindow: rotationWindow,
+		now:            time.Now,
+	}
+	for i, l := range loaded {
+		if i > 0 {
+			l.key.retiredAt = loaded[i-1].modTime
+		}
+		ks.keys[l.key.kid] = l.key
+	}
+
+	return ks, nil
+}
+
+func loadSigningKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var private any
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		kid:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		private: private,
+	}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key.method = jwt.SigningMethodRS256
+		key.public = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+
+	return key, nil
+}
+
+// usable reports whether the key may still verify tokens.
+func (ks *KeySet) usable(key *signingKey) bool {
+	return key.retiredAt.IsZero() || ks.now().Before(key.retiredAt.Add(ks.rotationWindow))
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	if ks.active.kid != "" {
+		token.Header["kid"] = ks.active.kid
+	}
+	return token.SignedString(ks.active.private)
+}
+
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok || !ks.usable(key) {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// JWK is the public part of a signing key as described in RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys downstream services may use to verify tokens.
+// Shared HMAC secrets are never published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if key.kid == "" || !ks.usable(key) {
+			continue
+		}
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch k := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writeKey(t *testing.T, dir, kid string, key any, modTime time.Time) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling key: %v", err)
+	}
+	path := filepath.Join(dir, kid+".pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("unexpected error writing key: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unexpected error setting key mtime: %v", err)
+	}
+}
+
+func TestLoadKeySet_SignsWithNewestKey(t *testing.T) {
+	dir := t.TempDir()
+	rotated := time.Now().Add(-time.Hour)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating RSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating Ed25519 key: %v", err)
+	}
+	writeKey(t, dir, "old-rsa", rsaKey, rotated.Add(-24*time.Hour))
+	writeKey(t, dir, "new-ed", edKey, rotated)
+
+	ks, err := LoadKeySet(dir, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error loading keys: %v", err)
+	}
+
+	userID := uuid.New()
+	token, err := ks.MakeJWT(userID, RoleUser, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating JWT: %v", err)
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("unexpected error parsing JWT: %v", err)
+	}
+	if parsed.Header["kid"] != "new-ed" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("expected EdDSA token with kid new-ed, got %v %v", parsed.Method.Alg(), parsed.Header["kid"])
+	}
+
+	claims, err := ks.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating JWT: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("expected %s, got %s", userID, claims.UserID)
+	}
+}
+
+func TestLoadKeySet_RotationWindow(t *testing.T) {
+	dir := t.TempDir()
+	rotated := time.Now().Add(-time.Hour)
+
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "old", oldKey, rotated.Add(-24*time.Hour))
+
+	oldSet, err := LoadKeySet(dir, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error loading keys: %v", err)
+	}
+	token, err := oldSet.MakeJWT(uuid.New(), RoleUser, 4*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating JWT: %v", err)
+	}
+
+	writeKey(t, dir, "new", newKey, rotated)
+	ks, err := LoadKeySet(dir, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error loading keys: %v", err)
+	}
+
+	if _, err := ks.ValidateJWT(token); err != nil {
+		t.Errorf("expected retired key to verify inside the rotation window, got %v", err)
+	}
+	if len(ks.JWKS().Keys) != 2 {
+		t.Errorf("expected both keys to be published, got %d", len(ks.JWKS().Keys))
+	}
+
+	ks.now = func() time.Time { return rotated.Add(3 * time.Hour) }
+	if _, err := ks.ValidateJWT(token); err == nil {
+		t.Error("expected error validating with a key past its rotation window, got nil")
+	}
+	if keys := ks.JWKS().Keys; len(keys) != 1 || keys[0].Kid != "new" {
+		t.Errorf("expected only the new key to be published, got %v", keys)
+	}
+}
+
+func TestValidateJWT_RejectsHMACWithPublicKey(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "ed", edKey, time.Now())
+
+	ks, err := LoadKeySet(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error loading keys: %v", err)
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: uuid.NewString()})
+	forged.Header["kid"] = "ed"
+	token, err := forged.SignedString([]byte(edKey.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatalf("unexpected error signing JWT: %v", err)
+	}
+
+	if _, err := ks.ValidateJWT(token); err == nil {
+		t.Error("expected error validating HS256 token against an EdDSA key, got nil")
+	}
+}
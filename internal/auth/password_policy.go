@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Codes identifying why a password was rejected.
+const (
+	PasswordTooShort     = "too_short"
+	PasswordTooLong      = "too_long"
+	PasswordMatchesEmail = "matches_email"
+	PasswordBreached     = "breached"
+)
+
+// PasswordViolation is one rule a password failed.
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicy decides which passwords may be set. Lengths count
+// characters, not bytes. A nil Breached skips the breached-password check.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	Breached  *BreachedPasswords
+}
+
+// Check returns every rule password fails for the account with the given
+// email, or nil when it is acceptable.
+func (p PasswordPolicy) Check(password, email string) []PasswordViolation {
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooLong,
+			Message: fmt.Sprintf("password must be at most %d characters", p.MaxLength),
+		})
+	}
+	if email != "" && strings.EqualFold(password, email) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordMatchesEmail,
+			Message: "password must not be the same as the email address",
+		})
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordBreached,
+			Message: "password has appeared in a data breach",
+		})
+	}
+
+	return violations
+}
+
+// BreachedPasswords is a set of known compromised passwords, held as SHA-1
+// hashes so the list never contains plaintext.
+type BreachedPasswords struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+// LoadBreachedPasswords reads a breached-password list from path. See
+// ReadBreachedPasswords for the format.
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBreachedPasswords(f)
+}
+
+// ReadBreachedPasswords reads a list in the Have I Been Pwned range format:
+// lines of "SUFFIX:COUNT", where SUFFIX is the last 35 hex digits of a SHA-1
+// hash, grouped under "PREFIX" lines holding the first 5. Lines carrying the
+// full 40 digit hash are accepted too, so output of the HIBP downloader can
+// be used as is. Blank lines and lines starting with # are skipped.
+func ReadBreachedPasswords(r io.Reader) (*BreachedPasswords, error) {
+	breached := &BreachedPasswords{hashes: map[[sha1.Size]byte]struct{}{}}
+
+	prefix := ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		digest, _, _ := strings.Cut(line, ":")
+
+		var full string
+		switch len(digest) {
+		case 5:
+			prefix = digest
+			continue
+		case 35:
+			if prefix == "" {
+				return nil, fmt.Errorf("line %d: hash suffix without a prefix", n)
+			}
+			full = prefix + digest
+		case 40:
+			full = digest
+		default:
+			return nil, fmt.Errorf("line %d: malformed hash %q", n, digest)
+		}
+
+		var hash [sha1.Size]byte
+		_, err := hex.Decode(hash[:], []byte(full))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		breached.hashes[hash] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return breached, nil
+}
+
+// Contains reports whether password is on the list.
+func (b *BreachedPasswords) Contains(password string) bool {
+	_, ok := b.hashes[sha1.Sum([]byte(password))]
+	return ok
+}
+
+// Len returns the number of hashes on the list.
+func (b *BreachedPasswords) Len() int {
+	return len(b.hashes)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8 and of
+// "letmein" B7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3.
+const testBreachedList = `# test list
+5BAA6
+1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824
+B7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3:1
+`
+
+func TestReadBreachedPasswords(t *testing.T) {
+	breached, err := ReadBreachedPasswords(strings.NewReader(testBreachedList))
+	if err != nil {
+		t.Fatalf("unexpected error reading list: %v", err)
+	}
+	if breached.Len() != 2 {
+		t.Errorf("expected 2 hashes, got %d", breached.Len())
+	}
+
+	for _, password := range []string{"password", "letmein"} {
+		if !breached.Contains(password) {
+			t.Errorf("expected %q to be breached", password)
+		}
+	}
+	if breached.Contains("correct horse battery staple") {
+		t.Error("expected unlisted password not to be breached")
+	}
+}
+
+func TestReadBreachedPasswords_Malformed(t *testing.T) {
+	for _, list := range []string{
+		"1E4C9B93F3F0682250B6CF8331B7EE68FD8:1\n",
+		"5BAA6\nnot-a-hash:1\n",
+		"5BAA6\nZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ:1\n",
+	} {
+		if _, err := ReadBreachedPasswords(strings.NewReader(list)); err == nil {
+			t.Errorf("expected error reading %q, got nil", list)
+		}
+	}
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	breached, err := ReadBreachedPasswords(strings.NewReader(testBreachedList))
+	if err != nil {
+		t.Fatalf("unexpected error reading list: %v", err)
+	}
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 16, Breached: breached}
+
+	tests := []struct {
+		password string
+		email    string
+		want     []string
+	}{
+		{"correct-horse", "walt@breakingbad.com", nil},
+		{"", "walt@breakingbad.com", []string{PasswordTooShort}},
+		{"ünïcödé", "walt@breakingbad.com", []string{PasswordTooShort}},
+		{"ünïcödé!", "walt@breakingbad.com", nil},
+		{"correct-horse-battery", "walt@breakingbad.com", []string{PasswordTooLong}},
+		{"W@lt.com", "w@lt.com", []string{PasswordMatchesEmail}},
+		{"password", "walt@breakingbad.com", []string{PasswordBreached}},
+		{"letmein", "walt@breakingbad.com", []string{PasswordTooShort, PasswordBreached}},
+	}
+
+	for _, tt := range tests {
+		violations := policy.Check(tt.password, tt.email)
+		var got []string
+		for _, v := range violations {
+			got = append(got, v.Code)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Check(%q): expected %v, got %v", tt.password, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package auth
+
+// Roles form a hierarchy: every role holds the privileges of those below it.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ValidRole reports whether role is a known role.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole reports whether role grants at least the privileges of required.
+func HasRole(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+// Scopes limit what a personal access token may do. Access tokens from a
+// login carry every scope. Reading chirps needs none, as chirps are public.
+const (
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeAccountRead  = "account:read"
+	ScopeAccountWrite = "account:write"
+)
+
+var Scopes = []string{ScopeChirpsWrite, ScopeAccountRead, ScopeAccountWrite}
+
+// personalAccessTokenPrefix tells personal access tokens apart from JWTs and
+// makes leaked tokens easy to spot with secret scanners.
+const personalAccessTokenPrefix = "chirpy_pat_"
+
+// ValidScopes reports whether scopes is a non-empty list of known scopes.
+func ValidScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// MakePersonalAccessToken returns a new random personal access token.
+func MakePersonalAccessToken() (string, error) {
+	token, err := MakeOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return personalAccessTokenPrefix + token, nil
+}
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access
+// token rather than a JWT.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, personalAccessTokenPrefix)
+}
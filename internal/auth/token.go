@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
@@ -43,6 +44,12 @@ func GetAPIKey(headers http.Header) (string, error) {
 }
 
 func MakeRefreshToken() (string, error) {
+	return MakeOpaqueToken()
+}
+
+// MakeOpaqueToken returns 256 random bits, hex encoded, for bearer secrets
+// such as single-use email links that are looked up by their HashToken digest.
+func MakeOpaqueToken() (string, error) {
 	key := make([]byte, 32)
 	_, err := rand.Read(key)
 	if err != nil {
@@ -50,3 +57,10 @@ func MakeRefreshToken() (string, error) {
 	}
 	return hex.EncodeToString(key), nil
 }
+
+// HashToken returns the hex SHA-256 digest of an opaque token. Tokens are
+// only ever stored in this form so a database dump cannot be replayed.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B secret ("12345678901234567890") in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("unexpected error generating code: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("at %d expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestValidateTOTP_Skew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previous, _ := TOTPCode(rfcSecret, now.Add(-30*time.Second))
+	stale, _ := TOTPCode(rfcSecret, now.Add(-90*time.Second))
+
+	step, ok := ValidateTOTP(rfcSecret, previous, now)
+	if !ok {
+		t.Fatal("expected code from the previous step to validate")
+	}
+	if step != now.Unix()/30-1 {
+		t.Errorf("expected step %d, got %d", now.Unix()/30-1, step)
+	}
+
+	if _, ok := ValidateTOTP(rfcSecret, stale, now); ok {
+		t.Error("expected code from three steps ago to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Chirpy", "walt@breakingbad.com", rfcSecret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Chirpy:walt@breakingbad.com?") {
+		t.Errorf("unexpected URI prefix: %s", uri)
+	}
+	if !strings.Contains(uri, "secret="+rfcSecret) {
+		t.Errorf("expected URI to contain the secret: %s", uri)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("unexpected error generating codes: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("expected 10 codes, got %d", len(codes))
+	}
+	for _, code := range codes {
+		if NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) != code {
+			t.Errorf("expected %s to survive normalization", code)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	ciphertext, err := Encrypt(key, []byte(rfcSecret))
+	if err != nil {
+		t.Fatalf("unexpected error encrypting: %v", err)
+	}
+	if bytes.Contains(ciphertext, []byte(rfcSecret)) {
+		t.Error("expected ciphertext not to contain the plaintext")
+	}
+
+	plaintext, err := Decrypt(key, ciphertext)
+	if err != nil {
+		t.Fatalf("unexpected error decrypting: %v", err)
+	}
+	if string(plaintext) != rfcSecret {
+		t.Errorf("expected %s, got %s", rfcSecret, plaintext)
+	}
+
+	if _, err := Decrypt(bytes.Repeat([]byte{8}, 32), ciphertext); err == nil {
+		t.Error("expected error decrypting with the wrong key, got nil")
+	}
+}
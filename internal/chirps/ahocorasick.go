@@ -0,0 +1,115 @@
+package chirps
+
+import "unicode"
+
+// automaton is an Aho-Corasick automaton finding every occurrence of a set of
+// patterns in one pass over the text, however many patterns there are.
+// Patterns and text are compared rune by rune with simple case folding, so
+// match offsets always line up with the original text.
+type automaton struct {
+	nodes    []acNode
+	patterns [][]rune
+}
+
+type acNode struct {
+	next map[rune]int
+	fail int
+	// out lists the patterns ending at this node, including those reached
+	// through fail links.
+	out []int
+}
+
+// acMatch is an occurrence of patterns[pattern] ending at rune index end
+// (exclusive) of the text.
+type acMatch struct {
+	pattern int
+	end     int
+}
+
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{nodes: []acNode{{next: map[rune]int{}}}}
+
+	for i, pattern := range patterns {
+		runes := foldRunes(pattern)
+		a.patterns = append(a.patterns, runes)
+		if len(runes) == 0 {
+			continue
+		}
+
+		node := 0
+		for _, r := range runes {
+			child, ok := a.nodes[node].next[r]
+			if !ok {
+				a.nodes = append(a.nodes, acNode{next: map[rune]int{}})
+				child = len(a.nodes) - 1
+				a.nodes[node].next[r] = child
+			}
+			node = child
+		}
+		a.nodes[node].out = append(a.nodes[node].out, i)
+	}
+
+	// Fail links point at the longest proper suffix that is also a prefix of
+	// some pattern. Nodes are visited breadth first so a node's fail target
+	// is always complete before its children need it.
+	queue := []int{}
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for r, child := range a.nodes[node].next {
+			fail := a.nodes[node].fail
+			for {
+				if target, ok := a.nodes[fail].next[r]; ok && target != child {
+					a.nodes[child].fail = target
+					break
+				}
+				if fail == 0 {
+					a.nodes[child].fail = 0
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			a.nodes[child].out = append(a.nodes[child].out, a.nodes[a.nodes[child].fail].out...)
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+// find returns every occurrence of the patterns in text, which is given as
+// folded runes.
+func (a *automaton) find(text []rune) []acMatch {
+	var matches []acMatch
+
+	node := 0
+	for i, r := range text {
+		for {
+			if next, ok := a.nodes[node].next[r]; ok {
+				node = next
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = a.nodes[node].fail
+		}
+		for _, pattern := range a.nodes[node].out {
+			matches = append(matches, acMatch{pattern: pattern, end: i + 1})
+		}
+	}
+
+	return matches
+}
+
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
@@ -0,0 +1,132 @@
+// Package chirps validates and cleans chirp bodies before they are stored.
+package chirps
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// DefaultMaxLength is the longest chirp allowed, in grapheme clusters.
+const DefaultMaxLength = 140
+
+// Redaction replaces the text matched by redact rules in a cleaned chirp.
+const Redaction = "****"
+
+// Codes identifying why a chirp was rejected.
+const (
+	ChirpEmpty       = "empty"
+	ChirpTooLong     = "too_long"
+	ChirpInvalidUTF8 = "invalid_utf8"
+	ChirpBlocked     = "blocked_content"
+)
+
+// Violation is one rule a chirp failed.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of running a chirp through a policy.
+type Result struct {
+	// Body is the chirp with redact rule matches replaced by Redaction.
+	Body string
+	// Violations lists every reason the chirp may not be posted.
+	Violations []Violation
+	// Hits lists every filter rule match, whatever its action.
+	Hits []Hit
+}
+
+// Flagged reports whether a flag rule matched, so the chirp should be
+// reviewed by a moderator.
+func (r Result) Flagged() bool {
+	for _, hit := range r.Hits {
+		if hit.Rule.Action == ActionFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy decides which chirps may be posted and how they are cleaned. Its
+// filter rules can be replaced at any time, including while chirps are being
+// checked.
+type Policy struct {
+	maxLength int
+	matcher   atomic.Pointer[Matcher]
+}
+
+// NewPolicy returns a policy allowing chirps of up to maxLength grapheme
+// clusters, with no filter rules.
+func NewPolicy(maxLength int) *Policy {
+	p := &Policy{maxLength: maxLength}
+	p.matcher.Store(&Matcher{automaton: newAutomaton(nil)})
+	return p
+}
+
+// SetMatcher replaces the filter rules the policy applies.
+func (p *Policy) SetMatcher(m *Matcher) {
+	p.matcher.Store(m)
+}
+
+// Length returns the length of body as a reader sees it: in grapheme
+// clusters, so that an emoji with modifiers or a letter with combining
+// accents counts once.
+func Length(body string) int {
+	return uniseg.GraphemeClusterCount(body)
+}
+
+// Apply checks body against the policy and cleans it.
+func (p *Policy) Apply(body string) Result {
+	result := Result{Body: body}
+
+	if !utf8.ValidString(body) {
+		result.Violations = append(result.Violations, Violation{
+			Code:    ChirpInvalidUTF8,
+			Message: "chirp must be valid UTF-8 text",
+		})
+	}
+	if strings.TrimSpace(body) == "" {
+		result.Violations = append(result.Violations, Violation{
+			Code:    ChirpEmpty,
+			Message: "chirp must not be empty",
+		})
+	}
+	if length := Length(body); length > p.maxLength {
+		result.Violations = append(result.Violations, Violation{
+			Code:    ChirpTooLong,
+			Message: fmt.Sprintf("chirp must be at most %d characters, got %d", p.maxLength, length),
+		})
+	}
+
+	result.Hits = p.matcher.Load().Match(body)
+
+	var redacted []Hit
+	blocked := map[string]bool{}
+	for _, hit := range result.Hits {
+		switch hit.Rule.Action {
+		case ActionRedact:
+			redacted = append(redacted, hit)
+		case ActionReject:
+			if blocked[hit.Rule.ID] {
+				continue
+			}
+			blocked[hit.Rule.ID] = true
+			result.Violations = append(result.Violations, Violation{
+				Code:    ChirpBlocked,
+				Message: fmt.Sprintf("chirp contains %q, which is not allowed", hit.Text),
+			})
+		}
+	}
+	result.Body = redact(body, redacted)
+
+	return result
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
@@ -0,0 +1,112 @@
+package chirps
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength_CountsGraphemeClusters(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{"hello", 5},
+		{"caf\u00e9", 4},
+		{"cafe\u0301", 4},
+		{"👍🏽", 1},
+		{"👨‍👩‍👧‍👦 family", 8},
+		{"🇮🇹🇬🇧", 2},
+	}
+
+	for _, tt := range tests {
+		if got := Length(tt.body); got != tt.want {
+			t.Errorf("Length(%q) = %d, want %d", tt.body, got, tt.want)
+		}
+	}
+}
+
+// profanity is the word list chirps were always cleaned of.
+func profanity() *Matcher {
+	var rules []Rule
+	for _, word := range []string{"kerfuffle", "sharbert", "fornax"} {
+		rules = append(rules, Rule{ID: word, Kind: RuleWord, Pattern: word, Action: ActionRedact})
+	}
+	m, err := NewMatcher(rules)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestPolicy_Violations(t *testing.T) {
+	p := NewPolicy(DefaultMaxLength)
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"ok", "I had something interesting for breakfast", nil},
+		{"exactly the limit", strings.Repeat("a", DefaultMaxLength), nil},
+		{"emoji at the limit", strings.Repeat("👍🏽", DefaultMaxLength), nil},
+		{"too long", strings.Repeat("a", DefaultMaxLength+1), []string{ChirpTooLong}},
+		{"empty", "", []string{ChirpEmpty}},
+		{"only whitespace", " \n\t", []string{ChirpEmpty}},
+		{"invalid and too long", strings.Repeat("\xff", DefaultMaxLength+1), []string{ChirpInvalidUTF8, ChirpTooLong}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := p.Apply(tt.body).Violations
+			if len(violations) != len(tt.want) {
+				t.Fatalf("expected violations %v, got %+v", tt.want, violations)
+			}
+			for i, violation := range violations {
+				if violation.Code != tt.want[i] {
+					t.Errorf("expected violation %s, got %s", tt.want[i], violation.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicy_Redacts(t *testing.T) {
+	p := NewPolicy(DefaultMaxLength)
+	p.SetMatcher(profanity())
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"This is a kerfuffle opinion I need to share with the world", "This is a **** opinion I need to share with the world"},
+		{"I really need a kerfuffle to go to bed sooner, Fornax !", "I really need a **** to go to bed sooner, **** !"},
+		{"What a Kerfuffle!", "What a ****!"},
+		{"(sharbert), \"fornax\"...", "(****), \"****\"..."},
+		{"sharbert's kerfuffle-free day", "****'s ****-free day"},
+		{"kerfuffled sharberts", "kerfuffled sharberts"},
+		{"line one\nfornax\ttab", "line one\n****\ttab"},
+		{"  spaced  out  ", "  spaced  out  "},
+	}
+
+	for _, tt := range tests {
+		result := p.Apply(tt.body)
+		if result.Body != tt.want {
+			t.Errorf("Apply(%q).Body = %q, want %q", tt.body, result.Body, tt.want)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("expected redaction alone not to reject %q, got %+v", tt.body, result.Violations)
+		}
+	}
+}
+
+func TestPolicy_SetMatcher(t *testing.T) {
+	p := NewPolicy(DefaultMaxLength)
+	if got := p.Apply("a kerfuffle").Body; got != "a kerfuffle" {
+		t.Errorf("expected no redaction without rules, got %q", got)
+	}
+
+	p.SetMatcher(profanity())
+	if got := p.Apply("a kerfuffle").Body; got != "a ****" {
+		t.Errorf("expected redaction after loading rules, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package chirps
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not made by
+// EncodeCursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a chirp listing: the sort key, (created_at, id), of
+// the last chirp on a page. The next page starts right after it. Search
+// results are ranked first, so their cursors also carry the rank.
+type Cursor struct {
+	Rank      float32
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type cursorJSON struct {
+	Rank      float32   `json:"r,omitempty"`
+	CreatedAt string    `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// EncodeCursor returns c as an opaque string for API clients.
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(cursorJSON{
+		Rank:      c.Rank,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339Nano),
+		ID:        c.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor made by EncodeCursor.
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c cursorJSON
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.ID == uuid.Nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, c.CreatedAt)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{Rank: c.Rank, CreatedAt: createdAt, ID: c.ID}, nil
+}
@@ -0,0 +1,38 @@
+package chirps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := Cursor{
+		Rank:      0.0607927,
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := DecodeCursor(EncodeCursor(c))
+	if err != nil {
+		t.Fatalf("unexpected error decoding cursor: %v", err)
+	}
+	if decoded.Rank != c.Rank || !decoded.CreatedAt.Equal(c.CreatedAt) || decoded.ID != c.ID {
+		t.Errorf("expected %+v, got %+v", c, decoded)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"not base64!",
+		"bm90IGpzb24",
+		"eyJ0IjoieWVzdGVyZGF5IiwiaWQiOiIwMDAwMDAwMC0wMDAwLTAwMDAtMDAwMC0wMDAwMDAwMDAwMDEifQ",
+		"eyJ0IjoiMjAyNC0wMy0wMVQxMjozMDoxNVoifQ",
+	} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) = %v, expected ErrInvalidCursor", s, err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package chirps
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kinds of filter rule.
+const (
+	RuleWord   = "word"
+	RulePhrase = "phrase"
+	RuleRegex  = "regex"
+)
+
+// What happens to a chirp matching a rule.
+const (
+	ActionRedact = "redact"
+	ActionReject = "reject"
+	ActionFlag   = "flag"
+)
+
+// Rule is a content filter. Words and phrases match case-insensitively and
+// only as whole words; regexes match wherever they match, case-insensitively.
+type Rule struct {
+	ID      string
+	Kind    string
+	Pattern string
+	Action  string
+}
+
+// Hit is an occurrence of a rule's pattern in a chirp. Start and End are byte
+// offsets into the chirp as posted.
+type Hit struct {
+	Rule  Rule
+	Start int
+	End   int
+	Text  string
+}
+
+// ValidateRule reports what is wrong with rule, if anything.
+func ValidateRule(rule Rule) error {
+	switch rule.Action {
+	case ActionRedact, ActionReject, ActionFlag:
+	default:
+		return fmt.Errorf("unknown action %q", rule.Action)
+	}
+
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return errors.New("pattern must not be empty")
+	}
+	switch rule.Kind {
+	case RuleWord:
+		if strings.ContainsFunc(rule.Pattern, unicode.IsSpace) {
+			return errors.New("a word must not contain spaces, use a phrase")
+		}
+	case RulePhrase:
+	case RuleRegex:
+		_, err := compileRegex(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", rule.Kind)
+	}
+	return nil
+}
+
+// Matcher finds the filter rules a chirp matches. It is immutable, so one
+// can be shared between goroutines and swapped out wholesale when the rules
+// change.
+type Matcher struct {
+	literals  []Rule
+	automaton *automaton
+	regexes   []compiledRegex
+}
+
+type compiledRegex struct {
+	rule Rule
+	re   *regexp.Regexp
+}
+
+// NewMatcher compiles rules into a matcher. Words and phrases all go into a
+// single Aho-Corasick automaton, so adding more of them barely costs anything
+// per chirp.
+func NewMatcher(rules []Rule) (*Matcher, error) {
+	m := &Matcher{}
+	var patterns []string
+	for _, rule := range rules {
+		err := ValidateRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+
+		if rule.Kind == RuleRegex {
+			re, _ := compileRegex(rule.Pattern)
+			m.regexes = append(m.regexes, compiledRegex{rule: rule, re: re})
+			continue
+		}
+		m.literals = append(m.literals, rule)
+		patterns = append(patterns, strings.TrimSpace(rule.Pattern))
+	}
+	m.automaton = newAutomaton(patterns)
+	return m, nil
+}
+
+// Match returns every hit of every rule in body, ordered by position.
+func (m *Matcher) Match(body string) []Hit {
+	var hits []Hit
+
+	if len(m.literals) > 0 {
+		// offsets[i] is the byte offset of rune i, with one extra entry for
+		// the end of body.
+		offsets := make([]int, 0, len(body)+1)
+		text := make([]rune, 0, len(body))
+		for i, r := range body {
+			offsets = append(offsets, i)
+			text = append(text, unicode.ToLower(r))
+		}
+		offsets = append(offsets, len(body))
+
+		for _, match := range m.automaton.find(text) {
+			pattern := m.automaton.patterns[match.pattern]
+			start := match.end - len(pattern)
+			if !wholeWord(text, pattern, start, match.end) {
+				continue
+			}
+			hits = append(hits, Hit{
+				Rule:  m.literals[match.pattern],
+				Start: offsets[start],
+				End:   offsets[match.end],
+				Text:  body[offsets[start]:offsets[match.end]],
+			})
+		}
+	}
+
+	for _, regex := range m.regexes {
+		for _, loc := range regex.re.FindAllStringIndex(body, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			hits = append(hits, Hit{Rule: regex.rule, Start: loc[0], End: loc[1], Text: body[loc[0]:loc[1]]})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Start != hits[j].Start {
+			return hits[i].Start < hits[j].Start
+		}
+		return hits[i].End > hits[j].End
+	})
+	return hits
+}
+
+// Len returns the number of rules in the matcher.
+func (m *Matcher) Len() int {
+	return len(m.literals) + len(m.regexes)
+}
+
+// wholeWord reports whether pattern, found at text[start:end], is not just
+// part of a longer word.
+func wholeWord(text, pattern []rune, start, end int) bool {
+	if start > 0 && isWordRune(pattern[0]) && isWordRune(text[start-1]) {
+		return false
+	}
+	if end < len(text) && isWordRune(pattern[len(pattern)-1]) && isWordRune(text[end]) {
+		return false
+	}
+	return true
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// redact replaces every hit in body with Redaction. Overlapping hits are
+// redacted as one.
+func redact(body string, hits []Hit) string {
+	if len(hits) == 0 {
+		return body
+	}
+
+	var out strings.Builder
+	out.Grow(len(body))
+
+	pos := 0
+	for _, hit := range hits {
+		if hit.End <= pos {
+			continue
+		}
+		if hit.Start >= pos {
+			out.WriteString(body[pos:hit.Start])
+			out.WriteString(Redaction)
+		}
+		pos = hit.End
+	}
+	out.WriteString(body[pos:])
+
+	return out.String()
+}
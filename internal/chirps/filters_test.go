@@ -0,0 +1,131 @@
+package chirps
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustMatcher(t *testing.T, rules ...Rule) *Matcher {
+	t.Helper()
+	m, err := NewMatcher(rules)
+	if err != nil {
+		t.Fatalf("unexpected error building matcher: %v", err)
+	}
+	return m
+}
+
+func hitTexts(hits []Hit) []string {
+	texts := []string{}
+	for _, hit := range hits {
+		texts = append(texts, hit.Rule.ID+":"+hit.Text)
+	}
+	return texts
+}
+
+func TestMatcher_OverlappingPatterns(t *testing.T) {
+	m := mustMatcher(t,
+		Rule{ID: "he", Kind: RuleWord, Pattern: "he", Action: ActionFlag},
+		Rule{ID: "she", Kind: RuleWord, Pattern: "she", Action: ActionFlag},
+		Rule{ID: "his", Kind: RuleWord, Pattern: "his", Action: ActionFlag},
+		Rule{ID: "she sells", Kind: RulePhrase, Pattern: "she sells", Action: ActionFlag},
+	)
+
+	got := hitTexts(m.Match("She sells, he said; this is his"))
+	want := []string{"she sells:She sells", "she:She", "he:he", "his:his"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected hits %v, got %v", want, got)
+	}
+}
+
+func TestMatcher_WholeWordsOnly(t *testing.T) {
+	m := mustMatcher(t,
+		Rule{ID: "ass", Kind: RuleWord, Pattern: "ass", Action: ActionRedact},
+		Rule{ID: "bad day", Kind: RulePhrase, Pattern: "bad day", Action: ActionRedact},
+	)
+
+	for _, body := range []string{"classic assassin", "a badday", "abad day", "bad days"} {
+		if hits := m.Match(body); len(hits) != 0 {
+			t.Errorf("expected no hits in %q, got %v", body, hitTexts(hits))
+		}
+	}
+	for _, body := range []string{"ass", "kick ASS!", "what a bad day.", "BAD DAY"} {
+		if hits := m.Match(body); len(hits) != 1 {
+			t.Errorf("expected one hit in %q, got %v", body, hitTexts(hits))
+		}
+	}
+}
+
+func TestMatcher_UnicodeOffsets(t *testing.T) {
+	m := mustMatcher(t, Rule{ID: "çà", Kind: RuleWord, Pattern: "ÇÀ", Action: ActionRedact})
+
+	body := "👍🏽 çà va"
+	hits := m.Match(body)
+	if len(hits) != 1 || hits[0].Text != "çà" || body[hits[0].Start:hits[0].End] != "çà" {
+		t.Errorf("unexpected hits %+v", hits)
+	}
+}
+
+func TestMatcher_Regex(t *testing.T) {
+	m := mustMatcher(t,
+		Rule{ID: "link", Kind: RuleRegex, Pattern: `https?://\S+`, Action: ActionFlag},
+		Rule{ID: "digits", Kind: RuleRegex, Pattern: `\d{3}-\d{4}`, Action: ActionRedact},
+	)
+
+	got := hitTexts(m.Match("call 555-1234 or see HTTP://example.com/x"))
+	want := []string{"digits:555-1234", "link:HTTP://example.com/x"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected hits %v, got %v", want, got)
+	}
+}
+
+func TestPolicy_Actions(t *testing.T) {
+	p := NewPolicy(DefaultMaxLength)
+	p.SetMatcher(mustMatcher(t,
+		Rule{ID: "fornax", Kind: RuleWord, Pattern: "fornax", Action: ActionRedact},
+		Rule{ID: "fornax gate", Kind: RulePhrase, Pattern: "fornax gate", Action: ActionRedact},
+		Rule{ID: "scam", Kind: RuleWord, Pattern: "scam", Action: ActionReject},
+		Rule{ID: "crypto", Kind: RuleRegex, Pattern: `crypto\w*`, Action: ActionFlag},
+	))
+
+	result := p.Apply("fornax gate! Cryptocurrency tips")
+	if result.Body != "****! Cryptocurrency tips" {
+		t.Errorf("unexpected body %q", result.Body)
+	}
+	if len(result.Violations) != 0 || !result.Flagged() {
+		t.Errorf("expected a flagged chirp without violations, got %+v", result)
+	}
+	if len(result.Hits) != 3 {
+		t.Errorf("expected 3 hits, got %v", hitTexts(result.Hits))
+	}
+
+	result = p.Apply("scam SCAM scam, fornax")
+	if len(result.Violations) != 1 || result.Violations[0].Code != ChirpBlocked {
+		t.Errorf("expected one blocked_content violation, got %+v", result.Violations)
+	}
+	if result.Flagged() {
+		t.Error("expected chirp not to be flagged")
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		rule  Rule
+		valid bool
+	}{
+		{Rule{Kind: RuleWord, Pattern: "kerfuffle", Action: ActionRedact}, true},
+		{Rule{Kind: RulePhrase, Pattern: "bad day", Action: ActionReject}, true},
+		{Rule{Kind: RuleRegex, Pattern: `\bfoo+\b`, Action: ActionFlag}, true},
+		{Rule{Kind: RuleWord, Pattern: "two words", Action: ActionRedact}, false},
+		{Rule{Kind: RuleWord, Pattern: " ", Action: ActionRedact}, false},
+		{Rule{Kind: RuleRegex, Pattern: `foo(`, Action: ActionFlag}, false},
+		{Rule{Kind: "glob", Pattern: "foo*", Action: ActionFlag}, false},
+		{Rule{Kind: RuleWord, Pattern: "foo", Action: "delete"}, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateRule(tt.rule)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateRule(%+v) = %v, expected valid %v", tt.rule, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package chirps
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptySearch is returned for a search query with nothing to look for.
+var ErrEmptySearch = errors.New("search query has no words to search for")
+
+// Search snippets are highlighted with private use characters, which cannot
+// be confused with anything in a chirp, and turned into <mark> tags only once
+// the rest of the snippet has been escaped.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// HeadlineOptions are the Postgres ts_headline options snippets are made with.
+const HeadlineOptions = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxFragments=2, MaxWords=20, MinWords=5`
+
+// ParseSearchQuery turns a search box query into a Postgres tsquery. Every
+// word must match; "quoted phrases" match their words in order; a trailing *
+// matches words starting with what precedes it; a leading - excludes a word
+// or phrase. Only letters and digits make it into the tsquery, so no input
+// can produce a malformed one.
+func ParseSearchQuery(q string) (string, error) {
+	var terms []string
+	positive := false
+
+	runes := []rune(q)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negate := runes[i] == '-'
+		if negate {
+			i++
+			if i == len(runes) {
+				break
+			}
+		}
+
+		var words []string
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			words = searchWords(string(runes[i+1 : end]))
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			raw := string(runes[i:end])
+			words = searchWords(raw)
+			if len(words) > 0 && strings.HasSuffix(raw, "*") {
+				words[len(words)-1] += ":*"
+			}
+			i = end
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		term := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+		if negate {
+			term = "!" + term
+		} else {
+			positive = true
+		}
+		terms = append(terms, term)
+	}
+
+	if !positive {
+		return "", ErrEmptySearch
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+func searchWords(s string) []string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return !isWordRune(r) })
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// RenderHeadline turns a snippet made with HeadlineOptions into HTML safe
+// text with the matches wrapped in <mark> tags.
+func RenderHeadline(headline string) string {
+	headline = html.EscapeString(headline)
+	headline = strings.ReplaceAll(headline, highlightStart, "<mark>")
+	return strings.ReplaceAll(headline, highlightStop, "</mark>")
+}
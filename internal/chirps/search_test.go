@@ -0,0 +1,48 @@
+package chirps
+
+import "testing"
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"kerfuffle", "kerfuffle"},
+		{"  Breakfast   TACOS ", "breakfast & tacos"},
+		{`"breaking bad" walt`, "(breaking <-> bad) & walt"},
+		{"chirp*", "chirp:*"},
+		{"e-mail", "(e <-> mail)"},
+		{"tacos -cilantro", "tacos & !cilantro"},
+		{`walt -"jesse pinkman"`, "walt & !(jesse <-> pinkman)"},
+		{`"unterminated phrase`, "(unterminated <-> phrase)"},
+		{"a:* | b & !c <-> (d)", "a:* & b & c & d"},
+		{"café", "café"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSearchQuery(tt.q)
+		if err != nil {
+			t.Errorf("ParseSearchQuery(%q) unexpected error: %v", tt.q, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSearchQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchQuery_Empty(t *testing.T) {
+	for _, q := range []string{"", "   ", "-tacos", `""`, "*", "&|!", "-"} {
+		if _, err := ParseSearchQuery(q); err != ErrEmptySearch {
+			t.Errorf("ParseSearchQuery(%q) = %v, expected ErrEmptySearch", q, err)
+		}
+	}
+}
+
+func TestRenderHeadline(t *testing.T) {
+	headline := "I <3 " + highlightStart + "tacos" + highlightStop + " & <script>"
+	want := "I &lt;3 <mark>tacos</mark> &amp; &lt;script&gt;"
+	if got := RenderHeadline(headline); got != want {
+		t.Errorf("RenderHeadline() = %q, want %q", got, want)
+	}
+}
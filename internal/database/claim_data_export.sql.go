@@ -0,0 +1,39 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: claim_data_export.sql
+
+package database
+
+import (
+	"context"
+)
+
+const claimDataExport = `-- name: ClaimDataExport :one
+UPDATE data_exports SET status = 'processing', updated_at = NOW()
+WHERE id = (
+    SELECT id FROM data_exports
+    WHERE status = 'pending'
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, status, error, size_bytes, expires_at, completed_at, created_at, updated_at
+`
+
+func (q *Queries) ClaimDataExport(ctx context.Context) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, claimDataExport)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.Error,
+		&i.SizeBytes,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
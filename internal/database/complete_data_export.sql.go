@@ -0,0 +1,30 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: complete_data_export.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const completeDataExport = `-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready', size_bytes = $2, expires_at = $3, completed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+type CompleteDataExportParams struct {
+	ID        uuid.UUID
+	SizeBytes sql.NullInt64
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CompleteDataExport(ctx context.Context, arg CompleteDataExportParams) error {
+	_, err := q.db.ExecContext(ctx, completeDataExport, arg.ID, arg.SizeBytes, arg.ExpiresAt)
+	return err
+}
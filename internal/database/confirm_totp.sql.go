@@ -0,0 +1,21 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: confirm_totp.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const confirmTOTP = `-- name: ConfirmTOTP :exec
+UPDATE user_totp SET confirmed_at = NOW(), updated_at = NOW() WHERE user_id = $1
+`
+
+func (q *Queries) ConfirmTOTP(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, confirmTOTP, userID)
+	return err
+}
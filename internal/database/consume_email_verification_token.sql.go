@@ -0,0 +1,30 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: consume_email_verification_token.sql
+
+package database
+
+import (
+	"context"
+)
+
+const consumeEmailVerificationToken = `-- name: ConsumeEmailVerificationToken :one
+UPDATE email_verification_tokens SET used_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and used_at IS NULL
+RETURNING token_hash, user_id, email, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (EmailVerificationToken, error) {
+	row := q.db.QueryRowContext(ctx, consumeEmailVerificationToken, tokenHash)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.UserID,
+		&i.Email,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
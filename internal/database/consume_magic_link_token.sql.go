@@ -0,0 +1,29 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: consume_magic_link_token.sql
+
+package database
+
+import (
+	"context"
+)
+
+const consumeMagicLinkToken = `-- name: ConsumeMagicLinkToken :one
+UPDATE magic_link_tokens SET used_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and used_at IS NULL
+RETURNING token_hash, user_id, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumeMagicLinkToken(ctx context.Context, tokenHash string) (MagicLinkToken, error) {
+	row := q.db.QueryRowContext(ctx, consumeMagicLinkToken, tokenHash)
+	var i MagicLinkToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,34 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: consume_oauth_authorization_code.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const consumeOAuthAuthorizationCode = `-- name: ConsumeOAuthAuthorizationCode :one
+UPDATE oauth_authorization_codes SET used_at = NOW()
+WHERE code_hash = $1 and expires_at > NOW() and used_at IS NULL
+RETURNING code_hash, grant_id, redirect_uri, code_challenge, scopes, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error) {
+	row := q.db.QueryRowContext(ctx, consumeOAuthAuthorizationCode, codeHash)
+	var i OauthAuthorizationCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.GrantID,
+		&i.RedirectUri,
+		&i.CodeChallenge,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
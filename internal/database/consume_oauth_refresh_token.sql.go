@@ -0,0 +1,32 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: consume_oauth_refresh_token.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const consumeOAuthRefreshToken = `-- name: ConsumeOAuthRefreshToken :one
+UPDATE oauth_refresh_tokens SET revoked_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL
+RETURNING token_hash, grant_id, scopes, expires_at, revoked_at, created_at
+`
+
+func (q *Queries) ConsumeOAuthRefreshToken(ctx context.Context, tokenHash string) (OauthRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, consumeOAuthRefreshToken, tokenHash)
+	var i OauthRefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.GrantID,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,35 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: consume_oidc_login_state.sql
+
+package database
+
+import (
+	"context"
+)
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 and provider = $2 and expires_at > NOW()
+RETURNING state_hash, provider, code_verifier, nonce, expires_at, created_at
+`
+
+type ConsumeOIDCLoginStateParams struct {
+	StateHash string
+	Provider  string
+}
+
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, arg ConsumeOIDCLoginStateParams) (OidcLoginState, error) {
+	row := q.db.QueryRowContext(ctx, consumeOIDCLoginState, arg.StateHash, arg.Provider)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.Provider,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
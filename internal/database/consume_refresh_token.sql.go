@@ -0,0 +1,35 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: consume_refresh_token.sql
+
+package database
+
+import (
+	"context"
+)
+
+const consumeRefreshToken = `-- name: ConsumeRefreshToken :one
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL
+RETURNING token_hash, user_id, expires_at, revoked_at, created_at, updated_at, family_id, parent_token_hash, user_agent, ip_address, session_started_at
+`
+
+func (q *Queries) ConsumeRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, consumeRefreshToken, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FamilyID,
+		&i.ParentTokenHash,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionStartedAt,
+	)
+	return i, err
+}
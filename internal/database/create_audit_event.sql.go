@@ -0,0 +1,41 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_audit_event.sql
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, event_type, actor_id, user_id, ip_address, user_agent, metadata, created_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW()
+       )
+`
+
+type CreateAuditEventParams struct {
+	EventType string
+	ActorID   uuid.NullUUID
+	UserID    uuid.NullUUID
+	IpAddress string
+	UserAgent string
+	Metadata  json.RawMessage
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.EventType,
+		arg.ActorID,
+		arg.UserID,
+		arg.IpAddress,
+		arg.UserAgent,
+		arg.Metadata,
+	)
+	return err
+}
@@ -20,7 +20,7 @@ VALUES (
         NOW(),
         NOW()
        )
-RETURNING id, body, user_id, created_at, updated_at
+RETURNING id, body, user_id, created_at, updated_at, search_vector, edited_at
 `
 
 type CreateChirpParams struct {
@@ -37,6 +37,8 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.EditedAt,
 	)
 	return i, err
 }
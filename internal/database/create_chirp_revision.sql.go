@@ -0,0 +1,44 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_chirp_revision.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createChirpRevision = `-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES (
+        gen_random_uuid(),
+        $1,
+        $2,
+        $3,
+        NOW()
+       )
+RETURNING id, chirp_id, body, created_at, replaced_at
+`
+
+type CreateChirpRevisionParams struct {
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateChirpRevision(ctx context.Context, arg CreateChirpRevisionParams) (ChirpRevision, error) {
+	row := q.db.QueryRowContext(ctx, createChirpRevision, arg.ChirpID, arg.Body, arg.CreatedAt)
+	var i ChirpRevision
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ReplacedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,47 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_content_filter.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createContentFilter = `-- name: CreateContentFilter :one
+INSERT INTO content_filters (id, kind, pattern, action, created_by, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, NOW(), NOW()
+       )
+RETURNING id, kind, pattern, action, created_by, created_at, updated_at
+`
+
+type CreateContentFilterParams struct {
+	Kind      string
+	Pattern   string
+	Action    string
+	CreatedBy uuid.NullUUID
+}
+
+func (q *Queries) CreateContentFilter(ctx context.Context, arg CreateContentFilterParams) (ContentFilter, error) {
+	row := q.db.QueryRowContext(ctx, createContentFilter,
+		arg.Kind,
+		arg.Pattern,
+		arg.Action,
+		arg.CreatedBy,
+	)
+	var i ContentFilter
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Pattern,
+		&i.Action,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,38 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_content_filter_hit.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createContentFilterHit = `-- name: CreateContentFilterHit :exec
+INSERT INTO content_filter_hits (id, filter_id, chirp_id, user_id, action, matched_text, created_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, NOW()
+       )
+`
+
+type CreateContentFilterHitParams struct {
+	FilterID    uuid.UUID
+	ChirpID     uuid.NullUUID
+	UserID      uuid.UUID
+	Action      string
+	MatchedText string
+}
+
+func (q *Queries) CreateContentFilterHit(ctx context.Context, arg CreateContentFilterHitParams) error {
+	_, err := q.db.ExecContext(ctx, createContentFilterHit,
+		arg.FilterID,
+		arg.ChirpID,
+		arg.UserID,
+		arg.Action,
+		arg.MatchedText,
+	)
+	return err
+}
@@ -0,0 +1,37 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_data_export.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createDataExport = `-- name: CreateDataExport :one
+INSERT INTO data_exports (id, user_id, status, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, 'pending', NOW(), NOW()
+       )
+RETURNING id, user_id, status, error, size_bytes, expires_at, completed_at, created_at, updated_at
+`
+
+func (q *Queries) CreateDataExport(ctx context.Context, userID uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, createDataExport, userID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.Error,
+		&i.SizeBytes,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
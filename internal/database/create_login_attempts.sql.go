@@ -0,0 +1,27 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_login_attempts.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const createLoginAttempts = `-- name: CreateLoginAttempts :exec
+INSERT INTO login_attempts (key, failures, last_failure_at)
+VALUES ($1, 0, $2)
+ON CONFLICT (key) DO NOTHING
+`
+
+type CreateLoginAttemptsParams struct {
+	Key           string
+	LastFailureAt time.Time
+}
+
+func (q *Queries) CreateLoginAttempts(ctx context.Context, arg CreateLoginAttemptsParams) error {
+	_, err := q.db.ExecContext(ctx, createLoginAttempts, arg.Key, arg.LastFailureAt)
+	return err
+}
@@ -0,0 +1,31 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_magic_link_token.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createMagicLinkToken = `-- name: CreateMagicLinkToken :exec
+INSERT INTO magic_link_tokens (token_hash, user_id, expires_at, created_at)
+VALUES (
+        $1, $2, $3, NOW()
+       )
+`
+
+type CreateMagicLinkTokenParams struct {
+	TokenHash string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateMagicLinkToken(ctx context.Context, arg CreateMagicLinkTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createMagicLinkToken, arg.TokenHash, arg.UserID, arg.ExpiresAt)
+	return err
+}
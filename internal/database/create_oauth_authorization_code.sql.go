@@ -0,0 +1,42 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_oauth_authorization_code.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createOAuthAuthorizationCode = `-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, grant_id, redirect_uri, code_challenge, scopes, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, $5, $6, NOW()
+       )
+`
+
+type CreateOAuthAuthorizationCodeParams struct {
+	CodeHash      string
+	GrantID       uuid.UUID
+	RedirectUri   string
+	CodeChallenge string
+	Scopes        []string
+	ExpiresAt     time.Time
+}
+
+func (q *Queries) CreateOAuthAuthorizationCode(ctx context.Context, arg CreateOAuthAuthorizationCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createOAuthAuthorizationCode,
+		arg.CodeHash,
+		arg.GrantID,
+		arg.RedirectUri,
+		arg.CodeChallenge,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	return err
+}
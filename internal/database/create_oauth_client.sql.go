@@ -0,0 +1,52 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_oauth_client.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createOAuthClient = `-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, owner_id, name, secret_hash, redirect_uris, scopes, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW()
+       )
+RETURNING id, owner_id, name, secret_hash, redirect_uris, scopes, created_at, updated_at
+`
+
+type CreateOAuthClientParams struct {
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   sql.NullString
+	RedirectUris []string
+	Scopes       []string
+}
+
+func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, createOAuthClient,
+		arg.OwnerID,
+		arg.Name,
+		arg.SecretHash,
+		pq.Array(arg.RedirectUris),
+		pq.Array(arg.Scopes),
+	)
+	var i OauthClient
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.SecretHash,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.Scopes),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
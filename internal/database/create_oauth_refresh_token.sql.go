@@ -0,0 +1,38 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_oauth_refresh_token.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createOAuthRefreshToken = `-- name: CreateOAuthRefreshToken :exec
+INSERT INTO oauth_refresh_tokens (token_hash, grant_id, scopes, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, NOW()
+       )
+`
+
+type CreateOAuthRefreshTokenParams struct {
+	TokenHash string
+	GrantID   uuid.UUID
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateOAuthRefreshToken(ctx context.Context, arg CreateOAuthRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createOAuthRefreshToken,
+		arg.TokenHash,
+		arg.GrantID,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	return err
+}
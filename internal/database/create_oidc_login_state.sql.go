@@ -0,0 +1,37 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_oidc_login_state.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, $5, NOW()
+       )
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash    string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error {
+	_, err := q.db.ExecContext(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.Provider,
+		arg.CodeVerifier,
+		arg.Nonce,
+		arg.ExpiresAt,
+	)
+	return err
+}
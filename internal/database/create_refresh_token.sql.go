@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,32 +15,57 @@ import (
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens (
-                            token, user_id, expires_at, created_at, updated_at
+                            token_hash, user_id, expires_at, family_id, parent_token_hash,
+                            user_agent, ip_address, session_started_at, created_at, updated_at
 ) VALUES (
           $1,
           $2,
             $3,
+          $4,
+          $5,
+          $6,
+          $7,
+          $8,
           NOW(),
           NOW()
-         ) RETURNING token, user_id, expires_at, revoked_at, created_at, updated_at
+         ) RETURNING token_hash, user_id, expires_at, revoked_at, created_at, updated_at, family_id, parent_token_hash, user_agent, ip_address, session_started_at
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
-	UserID    uuid.UUID
-	ExpiresAt time.Time
+	TokenHash        string
+	UserID           uuid.UUID
+	ExpiresAt        time.Time
+	FamilyID         uuid.UUID
+	ParentTokenHash  sql.NullString
+	UserAgent        string
+	IpAddress        string
+	SessionStartedAt time.Time
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.ParentTokenHash,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.SessionStartedAt,
+	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FamilyID,
+		&i.ParentTokenHash,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionStartedAt,
 	)
 	return i, err
 }
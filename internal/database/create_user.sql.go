@@ -14,7 +14,7 @@ INSERT INTO users (id, email, hashed_password, created_at, updated_at)
 VALUES (
         gen_random_uuid(), $1, $2, NOW(), NOW()
        )
-RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, email_verified_at, role, deletion_requested_at
 `
 
 type CreateUserParams struct {
@@ -32,6 +32,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.Role,
+		&i.DeletionRequestedAt,
 	)
 	return i, err
 }
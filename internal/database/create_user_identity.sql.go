@@ -0,0 +1,36 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: create_user_identity.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (id, user_id, provider, subject, email, created_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, NOW()
+       )
+`
+
+type CreateUserIdentityParams struct {
+	UserID   uuid.UUID
+	Provider string
+	Subject  string
+	Email    string
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Provider,
+		arg.Subject,
+		arg.Email,
+	)
+	return err
+}
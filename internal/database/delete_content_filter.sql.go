@@ -0,0 +1,24 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: delete_content_filter.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const deleteContentFilter = `-- name: DeleteContentFilter :execrows
+DELETE FROM content_filters WHERE id = $1
+`
+
+func (q *Queries) DeleteContentFilter(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteContentFilter, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
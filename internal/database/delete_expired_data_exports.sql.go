@@ -0,0 +1,19 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: delete_expired_data_exports.sql
+
+package database
+
+import (
+	"context"
+)
+
+const deleteExpiredDataExports = `-- name: DeleteExpiredDataExports :exec
+DELETE FROM data_exports WHERE expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredDataExports(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredDataExports)
+	return err
+}
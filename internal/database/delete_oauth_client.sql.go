@@ -0,0 +1,29 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: delete_oauth_client.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const deleteOAuthClient = `-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients WHERE id = $1 and owner_id = $2
+`
+
+type DeleteOAuthClientParams struct {
+	ID      uuid.UUID
+	OwnerID uuid.UUID
+}
+
+func (q *Queries) DeleteOAuthClient(ctx context.Context, arg DeleteOAuthClientParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOAuthClient, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
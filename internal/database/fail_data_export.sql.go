@@ -0,0 +1,27 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: fail_data_export.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const failDataExport = `-- name: FailDataExport :exec
+UPDATE data_exports SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW() WHERE id = $1
+`
+
+type FailDataExportParams struct {
+	ID    uuid.UUID
+	Error sql.NullString
+}
+
+func (q *Queries) FailDataExport(ctx context.Context, arg FailDataExportParams) error {
+	_, err := q.db.ExecContext(ctx, failDataExport, arg.ID, arg.Error)
+	return err
+}
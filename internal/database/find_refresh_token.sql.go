@@ -0,0 +1,33 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: find_refresh_token.sql
+
+package database
+
+import (
+	"context"
+)
+
+const findRefreshToken = `-- name: FindRefreshToken :one
+SELECT token_hash, user_id, expires_at, revoked_at, created_at, updated_at, family_id, parent_token_hash, user_agent, ip_address, session_started_at FROM refresh_tokens WHERE token_hash = $1
+`
+
+func (q *Queries) FindRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, findRefreshToken, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FamilyID,
+		&i.ParentTokenHash,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionStartedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,36 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_active_data_export_for_user.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getActiveDataExportForUser = `-- name: GetActiveDataExportForUser :one
+SELECT id, user_id, status, error, size_bytes, expires_at, completed_at, created_at, updated_at FROM data_exports
+WHERE user_id = $1 and status IN ('pending', 'processing')
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetActiveDataExportForUser(ctx context.Context, userID uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, getActiveDataExportForUser, userID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.Error,
+		&i.SizeBytes,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
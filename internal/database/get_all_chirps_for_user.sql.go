@@ -12,7 +12,9 @@ import (
 )
 
 const getAllChirpsForUser = `-- name: GetAllChirpsForUser :many
-SELECT id, body, user_id, created_at, updated_at FROM chirps WHERE user_id = $1 ORDER BY created_at
+SELECT id, body, user_id, created_at, updated_at, search_vector, edited_at FROM chirps
+WHERE user_id = $1 and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+ORDER BY created_at
 `
 
 func (q *Queries) GetAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -30,6 +32,8 @@ func (q *Queries) GetAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([]
 			&i.UserID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
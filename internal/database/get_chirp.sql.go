@@ -12,7 +12,8 @@ import (
 )
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, body, user_id, created_at, updated_at FROM chirps WHERE id = $1
+SELECT id, body, user_id, created_at, updated_at, search_vector, edited_at FROM chirps
+WHERE chirps.id = $1 and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -24,6 +25,8 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.EditedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,46 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_chirp_for_update.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.search_vector, chirps.edited_at,
+       chirps.created_at > NOW() - make_interval(secs => $1) AS editable
+FROM chirps
+WHERE chirps.id = $2
+FOR UPDATE
+`
+
+type GetChirpForUpdateParams struct {
+	EditWindowSeconds float64
+	ID                uuid.UUID
+}
+
+type GetChirpForUpdateRow struct {
+	Chirp    Chirp
+	Editable bool
+}
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, arg GetChirpForUpdateParams) (GetChirpForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, arg.EditWindowSeconds, arg.ID)
+	var i GetChirpForUpdateRow
+	err := row.Scan(
+		&i.Chirp.ID,
+		&i.Chirp.Body,
+		&i.Chirp.UserID,
+		&i.Chirp.CreatedAt,
+		&i.Chirp.UpdatedAt,
+		&i.Chirp.SearchVector,
+		&i.Chirp.EditedAt,
+		&i.Editable,
+	)
+	return i, err
+}
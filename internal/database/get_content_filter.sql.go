@@ -0,0 +1,31 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_content_filter.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getContentFilter = `-- name: GetContentFilter :one
+SELECT id, kind, pattern, action, created_by, created_at, updated_at FROM content_filters WHERE id = $1
+`
+
+func (q *Queries) GetContentFilter(ctx context.Context, id uuid.UUID) (ContentFilter, error) {
+	row := q.db.QueryRowContext(ctx, getContentFilter, id)
+	var i ContentFilter
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Pattern,
+		&i.Action,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
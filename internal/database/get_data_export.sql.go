@@ -0,0 +1,38 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_data_export.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getDataExport = `-- name: GetDataExport :one
+SELECT id, user_id, status, error, size_bytes, expires_at, completed_at, created_at, updated_at FROM data_exports WHERE id = $1 and user_id = $2
+`
+
+type GetDataExportParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetDataExport(ctx context.Context, arg GetDataExportParams) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, getDataExport, arg.ID, arg.UserID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.Error,
+		&i.SizeBytes,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,34 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_oauth_grant.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getOAuthGrant = `-- name: GetOAuthGrant :one
+SELECT id, user_id, client_id, scopes, revoked_at, created_at, updated_at FROM oauth_grants
+WHERE oauth_grants.id = $1 and revoked_at IS NULL
+  and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+`
+
+func (q *Queries) GetOAuthGrant(ctx context.Context, id uuid.UUID) (OauthGrant, error) {
+	row := q.db.QueryRowContext(ctx, getOAuthGrant, id)
+	var i OauthGrant
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ClientID,
+		pq.Array(&i.Scopes),
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
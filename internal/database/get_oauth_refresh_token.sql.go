@@ -0,0 +1,31 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_oauth_refresh_token.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const getOAuthRefreshToken = `-- name: GetOAuthRefreshToken :one
+SELECT token_hash, grant_id, scopes, expires_at, revoked_at, created_at FROM oauth_refresh_tokens
+WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL
+`
+
+func (q *Queries) GetOAuthRefreshToken(ctx context.Context, tokenHash string) (OauthRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getOAuthRefreshToken, tokenHash)
+	var i OauthRefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.GrantID,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
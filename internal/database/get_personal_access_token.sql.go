@@ -0,0 +1,36 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_personal_access_token.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const getPersonalAccessToken = `-- name: GetPersonalAccessToken :one
+SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at FROM personal_access_tokens
+WHERE token_hash = $1 and revoked_at IS NULL and (expires_at IS NULL or expires_at > NOW())
+  and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+`
+
+func (q *Queries) GetPersonalAccessToken(ctx context.Context, tokenHash string) (PersonalAccessToken, error) {
+	row := q.db.QueryRowContext(ctx, getPersonalAccessToken, tokenHash)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
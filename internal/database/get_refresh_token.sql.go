@@ -10,19 +10,24 @@ import (
 )
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, user_id, expires_at, revoked_at, created_at, updated_at FROM refresh_tokens WHERE token = $1 and expires_at > NOW() and revoked_at IS NULL
+SELECT token_hash, user_id, expires_at, revoked_at, created_at, updated_at, family_id, parent_token_hash, user_agent, ip_address, session_started_at FROM refresh_tokens WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, tokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FamilyID,
+		&i.ParentTokenHash,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionStartedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,37 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: get_user_by_identity.sql
+
+package database
+
+import (
+	"context"
+)
+
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red, email_verified_at, role, deletion_requested_at FROM users
+WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 and subject = $2)
+`
+
+type GetUserByIdentityParams struct {
+	Provider string
+	Subject  string
+}
+
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIdentity, arg.Provider, arg.Subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.Role,
+		&i.DeletionRequestedAt,
+	)
+	return i, err
+}
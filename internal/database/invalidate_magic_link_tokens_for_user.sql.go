@@ -0,0 +1,21 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: invalidate_magic_link_tokens_for_user.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const invalidateMagicLinkTokensForUser = `-- name: InvalidateMagicLinkTokensForUser :exec
+UPDATE magic_link_tokens SET used_at = NOW() WHERE user_id = $1 and used_at IS NULL
+`
+
+func (q *Queries) InvalidateMagicLinkTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, invalidateMagicLinkTokensForUser, userID)
+	return err
+}
@@ -0,0 +1,33 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: is_recent_session.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const isRecentSession = `-- name: IsRecentSession :one
+SELECT EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE family_id = $1 and user_id = $2 and revoked_at IS NULL
+      and session_started_at > NOW() - make_interval(secs => $3)
+)
+`
+
+type IsRecentSessionParams struct {
+	FamilyID      uuid.UUID
+	UserID        uuid.UUID
+	MaxAgeSeconds float64
+}
+
+func (q *Queries) IsRecentSession(ctx context.Context, arg IsRecentSessionParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isRecentSession, arg.FamilyID, arg.UserID, arg.MaxAgeSeconds)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
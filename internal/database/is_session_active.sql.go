@@ -0,0 +1,31 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: is_session_active.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const isSessionActive = `-- name: IsSessionActive :one
+SELECT EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE family_id = $1 and user_id = $2 and revoked_at IS NULL and expires_at > NOW()
+)
+`
+
+type IsSessionActiveParams struct {
+	FamilyID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) IsSessionActive(ctx context.Context, arg IsSessionActiveParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isSessionActive, arg.FamilyID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
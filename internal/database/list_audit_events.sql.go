@@ -0,0 +1,72 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_audit_events.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, event_type, actor_id, user_id, ip_address, user_agent, metadata, created_at FROM audit_events
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  and ($2::uuid IS NULL OR actor_id = $2)
+  and ($3::text IS NULL OR event_type = $3)
+  and ($4::timestamp IS NULL OR created_at >= $4)
+  and ($5::timestamp IS NULL OR created_at < $5)
+ORDER BY created_at DESC
+LIMIT $6
+`
+
+type ListAuditEventsParams struct {
+	UserID    uuid.NullUUID
+	ActorID   uuid.NullUUID
+	EventType sql.NullString
+	Since     sql.NullTime
+	Until     sql.NullTime
+	Limit     int32
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.UserID,
+		arg.ActorID,
+		arg.EventType,
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.ActorID,
+			&i.UserID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Metadata,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
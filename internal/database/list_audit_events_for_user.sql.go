@@ -0,0 +1,48 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_audit_events_for_user.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const listAuditEventsForUser = `-- name: ListAuditEventsForUser :many
+SELECT id, event_type, actor_id, user_id, ip_address, user_agent, metadata, created_at FROM audit_events WHERE user_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListAuditEventsForUser(ctx context.Context, userID uuid.NullUUID) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEventsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.ActorID,
+			&i.UserID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Metadata,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
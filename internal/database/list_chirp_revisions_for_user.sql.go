@@ -0,0 +1,48 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_chirp_revisions_for_user.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const listChirpRevisionsForUser = `-- name: ListChirpRevisionsForUser :many
+SELECT chirp_revisions.id, chirp_revisions.chirp_id, chirp_revisions.body, chirp_revisions.created_at, chirp_revisions.replaced_at FROM chirp_revisions
+JOIN chirps ON chirps.id = chirp_revisions.chirp_id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.chirp_id, chirp_revisions.replaced_at
+`
+
+func (q *Queries) ListChirpRevisionsForUser(ctx context.Context, userID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpRevisionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_content_filter_hits.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const listContentFilterHits = `-- name: ListContentFilterHits :many
+SELECT content_filter_hits.id, content_filter_hits.filter_id, content_filter_hits.chirp_id, content_filter_hits.user_id, content_filter_hits.action, content_filter_hits.matched_text, content_filter_hits.created_at, content_filters.kind, content_filters.pattern
+FROM content_filter_hits
+JOIN content_filters ON content_filters.id = content_filter_hits.filter_id
+WHERE ($1::uuid IS NULL OR content_filter_hits.filter_id = $1)
+  and ($2::text IS NULL OR content_filter_hits.action = $2)
+  and ($3::timestamp IS NULL OR content_filter_hits.created_at >= $3)
+ORDER BY content_filter_hits.created_at DESC
+LIMIT $4
+`
+
+type ListContentFilterHitsParams struct {
+	FilterID uuid.NullUUID
+	Action   sql.NullString
+	Since    sql.NullTime
+	Limit    int32
+}
+
+type ListContentFilterHitsRow struct {
+	ID          uuid.UUID
+	FilterID    uuid.UUID
+	ChirpID     uuid.NullUUID
+	UserID      uuid.UUID
+	Action      string
+	MatchedText string
+	CreatedAt   time.Time
+	Kind        string
+	Pattern     string
+}
+
+func (q *Queries) ListContentFilterHits(ctx context.Context, arg ListContentFilterHitsParams) ([]ListContentFilterHitsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listContentFilterHits,
+		arg.FilterID,
+		arg.Action,
+		arg.Since,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListContentFilterHitsRow
+	for rows.Next() {
+		var i ListContentFilterHitsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FilterID,
+			&i.ChirpID,
+			&i.UserID,
+			&i.Action,
+			&i.MatchedText,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.Pattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
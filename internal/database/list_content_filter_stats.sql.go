@@ -0,0 +1,48 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_content_filter_stats.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const listContentFilterStats = `-- name: ListContentFilterStats :many
+SELECT filter_id, COUNT(*) AS hit_count, MAX(created_at)::timestamp AS last_hit_at
+FROM content_filter_hits
+GROUP BY filter_id
+`
+
+type ListContentFilterStatsRow struct {
+	FilterID  uuid.UUID
+	HitCount  int64
+	LastHitAt time.Time
+}
+
+func (q *Queries) ListContentFilterStats(ctx context.Context) ([]ListContentFilterStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listContentFilterStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListContentFilterStatsRow
+	for rows.Next() {
+		var i ListContentFilterStatsRow
+		if err := rows.Scan(&i.FilterID, &i.HitCount, &i.LastHitAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -1,7 +1,7 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
 //   sqlc v1.30.0
-// source: get_all_chirps.sql
+// source: list_content_filters.sql
 
 package database
 
@@ -9,23 +9,25 @@ import (
 	"context"
 )
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, body, user_id, created_at, updated_at FROM chirps ORDER BY created_at
+const listContentFilters = `-- name: ListContentFilters :many
+SELECT id, kind, pattern, action, created_by, created_at, updated_at FROM content_filters ORDER BY created_at
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) ListContentFilters(ctx context.Context) ([]ContentFilter, error) {
+	rows, err := q.db.QueryContext(ctx, listContentFilters)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Chirp
+	var items []ContentFilter
 	for rows.Next() {
-		var i Chirp
+		var i ContentFilter
 		if err := rows.Scan(
 			&i.ID,
-			&i.Body,
-			&i.UserID,
+			&i.Kind,
+			&i.Pattern,
+			&i.Action,
+			&i.CreatedBy,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -0,0 +1,49 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_oauth_clients_for_owner.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const listOAuthClientsForOwner = `-- name: ListOAuthClientsForOwner :many
+SELECT id, owner_id, name, secret_hash, redirect_uris, scopes, created_at, updated_at FROM oauth_clients WHERE owner_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) ListOAuthClientsForOwner(ctx context.Context, ownerID uuid.UUID) ([]OauthClient, error) {
+	rows, err := q.db.QueryContext(ctx, listOAuthClientsForOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OauthClient
+	for rows.Next() {
+		var i OauthClient
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.SecretHash,
+			pq.Array(&i.RedirectUris),
+			pq.Array(&i.Scopes),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,59 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_oauth_grants_for_user.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const listOAuthGrantsForUser = `-- name: ListOAuthGrantsForUser :many
+SELECT oauth_grants.client_id, oauth_clients.name AS client_name, oauth_grants.scopes, oauth_grants.created_at, oauth_grants.updated_at
+FROM oauth_grants
+JOIN oauth_clients ON oauth_clients.id = oauth_grants.client_id
+WHERE oauth_grants.user_id = $1 and oauth_grants.revoked_at IS NULL
+ORDER BY oauth_grants.created_at DESC
+`
+
+type ListOAuthGrantsForUserRow struct {
+	ClientID   uuid.UUID
+	ClientName string
+	Scopes     []string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (q *Queries) ListOAuthGrantsForUser(ctx context.Context, userID uuid.UUID) ([]ListOAuthGrantsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOAuthGrantsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOAuthGrantsForUserRow
+	for rows.Next() {
+		var i ListOAuthGrantsForUserRow
+		if err := rows.Scan(
+			&i.ClientID,
+			&i.ClientName,
+			pq.Array(&i.Scopes),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,53 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: list_sessions_for_user.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const listSessionsForUser = `-- name: ListSessionsForUser :many
+SELECT token_hash, user_id, expires_at, revoked_at, created_at, updated_at, family_id, parent_token_hash, user_agent, ip_address, session_started_at FROM refresh_tokens
+WHERE user_id = $1 and expires_at > NOW() and revoked_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListSessionsForUser(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.TokenHash,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FamilyID,
+			&i.ParentTokenHash,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.SessionStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
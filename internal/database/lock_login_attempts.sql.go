@@ -0,0 +1,21 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: lock_login_attempts.sql
+
+package database
+
+import (
+	"context"
+)
+
+const lockLoginAttempts = `-- name: LockLoginAttempts :one
+SELECT key, failures, last_failure_at FROM login_attempts WHERE key = $1 FOR UPDATE
+`
+
+func (q *Queries) LockLoginAttempts(ctx context.Context, key string) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, lockLoginAttempts, key)
+	var i LoginAttempt
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailureAt)
+	return i, err
+}
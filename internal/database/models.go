@@ -6,33 +6,214 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	ID        uuid.UUID
+	EventType string
+	ActorID   uuid.NullUUID
+	UserID    uuid.NullUUID
+	IpAddress string
+	UserAgent string
+	Metadata  json.RawMessage
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID           uuid.UUID
+	Body         string
+	UserID       uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	SearchVector sql.NullString
+	EditedAt     sql.NullTime
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type ContentFilter struct {
 	ID        uuid.UUID
-	Body      string
-	UserID    uuid.UUID
+	Kind      string
+	Pattern   string
+	Action    string
+	CreatedBy uuid.NullUUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-type RefreshToken struct {
-	Token     string
+type ContentFilterHit struct {
+	ID          uuid.UUID
+	FilterID    uuid.UUID
+	ChirpID     uuid.NullUUID
+	UserID      uuid.UUID
+	Action      string
+	MatchedText string
+	CreatedAt   time.Time
+}
+
+type DataExport struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      string
+	Error       sql.NullString
+	SizeBytes   sql.NullInt64
+	ExpiresAt   sql.NullTime
+	CompletedAt sql.NullTime
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
 	UserID    uuid.UUID
+	Email     string
 	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type LoginAttempt struct {
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+}
+
+type MagicLinkToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type MfaRecoveryCode struct {
+	CodeHash  string
+	UserID    uuid.UUID
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	GrantID       uuid.UUID
+	RedirectUri   string
+	CodeChallenge string
+	Scopes        []string
+	ExpiresAt     time.Time
+	UsedAt        sql.NullTime
+	CreatedAt     time.Time
+}
+
+type OauthClient struct {
+	ID           uuid.UUID
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   sql.NullString
+	RedirectUris []string
+	Scopes       []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type OauthGrant struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ClientID  uuid.UUID
+	Scopes    []string
 	RevokedAt sql.NullTime
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+type OauthRefreshToken struct {
+	TokenHash string
+	GrantID   uuid.UUID
+	Scopes    []string
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+	CreatedAt time.Time
+}
+
+type OidcLoginState struct {
+	StateHash    string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type RefreshToken struct {
+	TokenHash        string
+	UserID           uuid.UUID
+	ExpiresAt        time.Time
+	RevokedAt        sql.NullTime
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	FamilyID         uuid.UUID
+	ParentTokenHash  sql.NullString
+	UserAgent        string
+	IpAddress        string
+	SessionStartedAt time.Time
+}
+
 type User struct {
-	ID             uuid.UUID
-	Email          string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                  uuid.UUID
+	Email               string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	HashedPassword      string
+	IsChirpyRed         bool
+	EmailVerifiedAt     sql.NullTime
+	Role                string
+	DeletionRequestedAt sql.NullTime
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+type UserTotp struct {
+	UserID           uuid.UUID
+	SecretCiphertext []byte
+	ConfirmedAt      sql.NullTime
+	LastUsedStep     int64
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
@@ -0,0 +1,38 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: record_login_failure.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (key, failures, last_failure_at)
+VALUES (
+        $1, 1, $2
+       )
+ON CONFLICT (key) DO UPDATE
+    SET failures = CASE
+                       WHEN login_attempts.last_failure_at < $3 THEN 1
+                       ELSE login_attempts.failures + 1
+                   END,
+        last_failure_at = EXCLUDED.last_failure_at
+RETURNING key, failures, last_failure_at
+`
+
+type RecordLoginFailureParams struct {
+	Key          string
+	FailedAt     time.Time
+	ForgetBefore time.Time
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Key, arg.FailedAt, arg.ForgetBefore)
+	var i LoginAttempt
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailureAt)
+	return i, err
+}
@@ -0,0 +1,19 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: release_login_failure.sql
+
+package database
+
+import (
+	"context"
+)
+
+const releaseLoginFailure = `-- name: ReleaseLoginFailure :exec
+UPDATE login_attempts SET failures = GREATEST(failures - 1, 0) WHERE key = $1
+`
+
+func (q *Queries) ReleaseLoginFailure(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, releaseLoginFailure, key)
+	return err
+}
@@ -0,0 +1,33 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: request_user_deletion.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const requestUserDeletion = `-- name: RequestUserDeletion :one
+UPDATE users SET deletion_requested_at = NOW(), updated_at = NOW() WHERE id = $1 RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, email_verified_at, role, deletion_requested_at
+`
+
+func (q *Queries) RequestUserDeletion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, requestUserDeletion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.Role,
+		&i.DeletionRequestedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,20 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: requeue_stale_data_exports.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const requeueStaleDataExports = `-- name: RequeueStaleDataExports :exec
+UPDATE data_exports SET status = 'pending', updated_at = NOW() WHERE status = 'processing' and updated_at < $1
+`
+
+func (q *Queries) RequeueStaleDataExports(ctx context.Context, updatedAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, requeueStaleDataExports, updatedAt)
+	return err
+}
@@ -0,0 +1,19 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: reset_login_attempts.sql
+
+package database
+
+import (
+	"context"
+)
+
+const resetLoginAttempts = `-- name: ResetLoginAttempts :exec
+DELETE FROM login_attempts WHERE key = $1
+`
+
+func (q *Queries) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, resetLoginAttempts, key)
+	return err
+}
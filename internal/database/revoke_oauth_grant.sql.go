@@ -0,0 +1,30 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: revoke_oauth_grant.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const revokeOAuthGrant = `-- name: RevokeOAuthGrant :execrows
+UPDATE oauth_grants SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 and client_id = $2 and revoked_at IS NULL
+`
+
+type RevokeOAuthGrantParams struct {
+	UserID   uuid.UUID
+	ClientID uuid.UUID
+}
+
+func (q *Queries) RevokeOAuthGrant(ctx context.Context, arg RevokeOAuthGrantParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeOAuthGrant, arg.UserID, arg.ClientID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
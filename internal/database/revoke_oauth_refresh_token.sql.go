@@ -0,0 +1,19 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: revoke_oauth_refresh_token.sql
+
+package database
+
+import (
+	"context"
+)
+
+const revokeOAuthRefreshToken = `-- name: RevokeOAuthRefreshToken :exec
+UPDATE oauth_refresh_tokens SET revoked_at = NOW() WHERE token_hash = $1 and revoked_at IS NULL
+`
+
+func (q *Queries) RevokeOAuthRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, revokeOAuthRefreshToken, tokenHash)
+	return err
+}
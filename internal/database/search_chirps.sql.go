@@ -0,0 +1,95 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: search_chirps.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.edited_at,
+       ts_rank(chirps.search_vector, query)::real AS rank,
+       ts_headline('english', chirps.body, query, $1)::text AS snippet
+FROM chirps, to_tsquery('english', $2) query
+WHERE chirps.search_vector @@ query
+  and ($3::uuid IS NULL OR chirps.user_id = $3)
+  and ($4::timestamp IS NULL OR chirps.created_at >= $4)
+  and ($5::timestamp IS NULL OR chirps.created_at < $5)
+  and ($6::real IS NULL
+       OR (ts_rank(chirps.search_vector, query), chirps.created_at, chirps.id) < ($6, $7::timestamp, $8::uuid))
+  and chirps.user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $9
+`
+
+type SearchChirpsParams struct {
+	HeadlineOptions string
+	Query           string
+	AuthorID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	AfterRank       sql.NullFloat64
+	AfterCreatedAt  sql.NullTime
+	AfterID         uuid.NullUUID
+	Limit           int32
+}
+
+type SearchChirpsRow struct {
+	ID        uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	EditedAt  sql.NullTime
+	Rank      float32
+	Snippet   string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.HeadlineOptions,
+		arg.Query,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.AfterRank,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EditedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
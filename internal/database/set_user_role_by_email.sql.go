@@ -0,0 +1,36 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: set_user_role_by_email.sql
+
+package database
+
+import (
+	"context"
+)
+
+const setUserRoleByEmail = `-- name: SetUserRoleByEmail :one
+UPDATE users SET role = $2, updated_at = NOW() WHERE email = $1 RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, email_verified_at, role, deletion_requested_at
+`
+
+type SetUserRoleByEmailParams struct {
+	Email string
+	Role  string
+}
+
+func (q *Queries) SetUserRoleByEmail(ctx context.Context, arg SetUserRoleByEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRoleByEmail, arg.Email, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.Role,
+		&i.DeletionRequestedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,43 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: update_content_filter.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const updateContentFilter = `-- name: UpdateContentFilter :one
+UPDATE content_filters SET kind = $2, pattern = $3, action = $4, updated_at = NOW() WHERE id = $1 RETURNING id, kind, pattern, action, created_by, created_at, updated_at
+`
+
+type UpdateContentFilterParams struct {
+	ID      uuid.UUID
+	Kind    string
+	Pattern string
+	Action  string
+}
+
+func (q *Queries) UpdateContentFilter(ctx context.Context, arg UpdateContentFilterParams) (ContentFilter, error) {
+	row := q.db.QueryRowContext(ctx, updateContentFilter,
+		arg.ID,
+		arg.Kind,
+		arg.Pattern,
+		arg.Action,
+	)
+	var i ContentFilter
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Pattern,
+		&i.Action,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
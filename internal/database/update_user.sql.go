@@ -1,36 +0,0 @@
-// Code generated by sqlc. DO NOT EDIT.
-// versions:
-//   sqlc v1.30.0
-// source: update_user.sql
-
-package database
-
-import (
-	"context"
-
-	"github.com/google/uuid"
-)
-
-const updateUser = `-- name: UpdateUser :one
-UPDATE users SET email = $2, hashed_password = $3 WHERE id = $1 RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
-`
-
-type UpdateUserParams struct {
-	ID             uuid.UUID
-	Email          string
-	HashedPassword string
-}
-
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.HashedPassword,
-		&i.IsChirpyRed,
-	)
-	return i, err
-}
@@ -12,7 +12,7 @@ import (
 )
 
 const upgradeUser = `-- name: UpgradeUser :one
-UPDATE users SET is_chirpy_red = true where id = $1 RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+UPDATE users SET is_chirpy_red = true, updated_at = NOW() where id = $1 RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, email_verified_at, role, deletion_requested_at
 `
 
 func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -25,6 +25,9 @@ func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.Role,
+		&i.DeletionRequestedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,44 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: upsert_oauth_grant.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const upsertOAuthGrant = `-- name: UpsertOAuthGrant :one
+INSERT INTO oauth_grants (id, user_id, client_id, scopes, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, NOW(), NOW()
+       )
+ON CONFLICT (user_id, client_id) WHERE revoked_at IS NULL
+DO UPDATE SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_grants.scopes || EXCLUDED.scopes)), updated_at = NOW()
+RETURNING id, user_id, client_id, scopes, revoked_at, created_at, updated_at
+`
+
+type UpsertOAuthGrantParams struct {
+	UserID   uuid.UUID
+	ClientID uuid.UUID
+	Scopes   []string
+}
+
+func (q *Queries) UpsertOAuthGrant(ctx context.Context, arg UpsertOAuthGrantParams) (OauthGrant, error) {
+	row := q.db.QueryRowContext(ctx, upsertOAuthGrant, arg.UserID, arg.ClientID, pq.Array(arg.Scopes))
+	var i OauthGrant
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ClientID,
+		pq.Array(&i.Scopes),
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,42 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: upsert_totp.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const upsertTOTP = `-- name: UpsertTOTP :one
+INSERT INTO user_totp (user_id, secret_ciphertext, created_at, updated_at)
+VALUES (
+        $1, $2, NOW(), NOW()
+       )
+ON CONFLICT (user_id) DO UPDATE
+    SET secret_ciphertext = EXCLUDED.secret_ciphertext, last_used_step = 0, updated_at = NOW()
+    WHERE user_totp.confirmed_at IS NULL
+RETURNING user_id, secret_ciphertext, confirmed_at, last_used_step, created_at, updated_at
+`
+
+type UpsertTOTPParams struct {
+	UserID           uuid.UUID
+	SecretCiphertext []byte
+}
+
+func (q *Queries) UpsertTOTP(ctx context.Context, arg UpsertTOTPParams) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, upsertTOTP, arg.UserID, arg.SecretCiphertext)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.SecretCiphertext,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,29 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: use_totp_step.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE user_totp SET last_used_step = $2, updated_at = NOW() WHERE user_id = $1 and last_used_step < $2
+`
+
+type UseTOTPStepParams struct {
+	UserID       uuid.UUID
+	LastUsedStep int64
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
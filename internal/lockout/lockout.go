@@ -0,0 +1,96 @@
+// Package lockout throttles repeated authentication failures with exponential
+// backoff and temporary lockouts.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Attempts is the failure history of one key, such as an account or an IP.
+type Attempts struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// Store persists failure counters. Failures older than forgetBefore no longer
+// count, so Reserve starts such keys over.
+//
+// Reserve must be atomic: it reads the history of key and, unless wait says
+// the key has to wait, counts an attempt made at as a failure before anyone
+// else can read the history. It returns the wait, zero when the attempt was
+// counted. Release takes back one failure counted by Reserve.
+type Store interface {
+	Reserve(ctx context.Context, key string, at, forgetBefore time.Time, wait func(Attempts) time.Duration) (time.Duration, error)
+	Release(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy describes how failures are punished. After the first failure a key
+// waits BaseDelay, doubling with every further failure up to MaxDelay. Once a
+// key reaches MaxFailures it is locked out for LockoutDuration. Failures are
+// forgotten Window after the last one.
+type Policy struct {
+	MaxFailures     int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+// RetryAfter returns how long a key with the given history must wait before
+// its next attempt, or zero if it may try now.
+func (p Policy) RetryAfter(a Attempts, now time.Time) time.Duration {
+	if a.Failures == 0 || now.Sub(a.LastFailureAt) >= p.Window {
+		return 0
+	}
+
+	wait := p.LockoutDuration
+	if a.Failures < p.MaxFailures {
+		wait = p.MaxDelay
+		if shift := a.Failures - 1; shift < 32 && p.BaseDelay<<shift < p.MaxDelay {
+			wait = p.BaseDelay << shift
+		}
+	}
+
+	until := a.LastFailureAt.Add(wait)
+	if now.Before(until) {
+		return until.Sub(now)
+	}
+	return 0
+}
+
+// Limiter applies a Policy to the counters in a Store.
+type Limiter struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, policy Policy) *Limiter {
+	return &Limiter{store: store, policy: policy, now: time.Now}
+}
+
+// Attempt returns how long key must wait before its next attempt, or zero if
+// it may try now. An attempt that may go ahead is counted as a failure right
+// away, before the credentials are even checked, so concurrent guesses cannot
+// all get through before the first of them fails. Callers take it back with
+// Release or Reset once the attempt succeeds.
+func (l *Limiter) Attempt(ctx context.Context, key string) (time.Duration, error) {
+	now := l.now()
+	return l.store.Reserve(ctx, key, now, now.Add(-l.policy.Window), func(a Attempts) time.Duration {
+		return l.policy.RetryAfter(a, now)
+	})
+}
+
+// Release takes back an attempt counted by Attempt that did not fail, leaving
+// the rest of the history of key alone.
+func (l *Limiter) Release(ctx context.Context, key string) error {
+	return l.store.Release(ctx, key)
+}
+
+// Reset clears the history of key, after a successful attempt or when an
+// administrator unlocks it.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, key)
+}
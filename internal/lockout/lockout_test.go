@@ -0,0 +1,154 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	MaxFailures:     4,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Second,
+	LockoutDuration: 15 * time.Minute,
+	Window:          time.Hour,
+}
+
+func TestPolicy_RetryAfter(t *testing.T) {
+	last := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		now      time.Time
+		want     time.Duration
+	}{
+		{"no failures", 0, last, 0},
+		{"first failure", 1, last, time.Second},
+		{"backoff doubles", 3, last, 4 * time.Second},
+		{"backoff elapsed", 3, last.Add(5 * time.Second), 0},
+		{"locked out", 4, last.Add(time.Minute), 14 * time.Minute},
+		{"lockout elapsed", 4, last.Add(15 * time.Minute), 0},
+		{"forgotten", 10, last.Add(time.Hour), 0},
+	}
+
+	for _, tt := range tests {
+		got := testPolicy.RetryAfter(Attempts{Failures: tt.failures, LastFailureAt: last}, tt.now)
+		if got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestPolicy_RetryAfterCapsBackoff(t *testing.T) {
+	policy := testPolicy
+	policy.MaxFailures = 100
+	last := time.Now()
+
+	got := policy.RetryAfter(Attempts{Failures: 64, LastFailureAt: last}, last)
+	if got != policy.MaxDelay {
+		t.Errorf("expected backoff capped at %s, got %s", policy.MaxDelay, got)
+	}
+}
+
+func TestLimiter_LocksAndResets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore(), testPolicy)
+	limiter.now = func() time.Time { return now }
+
+	for i := range testPolicy.MaxFailures {
+		retryAfter, err := limiter.Attempt(ctx, "account:walt@breakingbad.com")
+		if err != nil {
+			t.Fatalf("unexpected error starting attempt: %v", err)
+		}
+		if retryAfter != 0 {
+			t.Fatalf("expected attempt %d to go ahead, got wait of %s", i+1, retryAfter)
+		}
+		now = now.Add(testPolicy.MaxDelay)
+	}
+
+	retryAfter, err := limiter.Attempt(ctx, "account:walt@breakingbad.com")
+	if err != nil {
+		t.Fatalf("unexpected error starting attempt: %v", err)
+	}
+	if want := testPolicy.LockoutDuration - testPolicy.MaxDelay; retryAfter != want {
+		t.Errorf("expected lockout of %s, got %s", want, retryAfter)
+	}
+
+	if retryAfter, _ := limiter.Attempt(ctx, "account:jesse@breakingbad.com"); retryAfter != 0 {
+		t.Errorf("expected other keys to be unaffected, got %s", retryAfter)
+	}
+
+	if err := limiter.Reset(ctx, "account:walt@breakingbad.com"); err != nil {
+		t.Fatalf("unexpected error resetting key: %v", err)
+	}
+	if retryAfter, _ := limiter.Attempt(ctx, "account:walt@breakingbad.com"); retryAfter != 0 {
+		t.Errorf("expected no wait after reset, got %s", retryAfter)
+	}
+}
+
+func TestLimiter_ConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(NewMemoryStore(), testPolicy)
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retryAfter, err := limiter.Attempt(ctx, "account:walt@breakingbad.com")
+			if err == nil && retryAfter == 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 1 {
+		t.Errorf("expected exactly one concurrent attempt to go ahead, got %d", n)
+	}
+}
+
+func TestLimiter_ReleaseTakesBackAttempt(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore(), testPolicy)
+	limiter.now = func() time.Time { return now }
+
+	if retryAfter, _ := limiter.Attempt(ctx, "ip:127.0.0.1"); retryAfter != 0 {
+		t.Fatalf("expected first attempt to go ahead, got %s", retryAfter)
+	}
+	if err := limiter.Release(ctx, "ip:127.0.0.1"); err != nil {
+		t.Fatalf("unexpected error releasing attempt: %v", err)
+	}
+	if retryAfter, _ := limiter.Attempt(ctx, "ip:127.0.0.1"); retryAfter != 0 {
+		t.Errorf("expected no wait after a released attempt, got %s", retryAfter)
+	}
+}
+
+func TestMemoryStore_ForgetsOldFailures(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Now()
+	noWait := func(Attempts) time.Duration { return 0 }
+
+	_, _ = store.Reserve(ctx, "ip:127.0.0.1", start, start.Add(-time.Hour), noWait)
+	_, _ = store.Reserve(ctx, "ip:127.0.0.1", start, start.Add(-time.Hour), noWait)
+
+	later := start.Add(2 * time.Hour)
+	var seen Attempts
+	_, err := store.Reserve(ctx, "ip:127.0.0.1", later, later.Add(-time.Hour), func(a Attempts) time.Duration {
+		seen = a
+		return 0
+	})
+	if err != nil {
+		t.Fatalf("unexpected error reserving attempt: %v", err)
+	}
+	if seen.Failures != 0 {
+		t.Errorf("expected counter to start over, got %d failures", seen.Failures)
+	}
+}
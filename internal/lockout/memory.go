@@ -0,0 +1,72 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxMemoryKeys bounds the memory store; past it, forgotten keys are pruned.
+const maxMemoryKeys = 100_000
+
+// MemoryStore keeps counters in process memory. It suits single instance
+// deployments; counters reset on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: map[string]Attempts{}}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key string, at, forgetBefore time.Time, wait func(Attempts) time.Duration) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.attempts) >= maxMemoryKeys {
+		for k, a := range s.attempts {
+			if a.LastFailureAt.Before(forgetBefore) {
+				delete(s.attempts, k)
+			}
+		}
+	}
+
+	attempts := s.attempts[key]
+	if attempts.LastFailureAt.Before(forgetBefore) {
+		attempts.Failures = 0
+	}
+	if d := wait(attempts); d > 0 {
+		return d, nil
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	s.attempts[key] = attempts
+
+	return 0, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts, ok := s.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempts.Failures--
+	if attempts.Failures <= 0 {
+		delete(s.attempts, key)
+		return nil
+	}
+	s.attempts[key] = attempts
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
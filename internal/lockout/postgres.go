@@ -0,0 +1,69 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// PostgresStore keeps counters in the login_attempts table so every instance
+// of a multi-instance deployment sees the same failures.
+type PostgresStore struct {
+	conn *sql.DB
+	db   *database.Queries
+}
+
+func NewPostgresStore(conn *sql.DB) *PostgresStore {
+	return &PostgresStore{conn: conn, db: database.New(conn)}
+}
+
+func (s *PostgresStore) Reserve(ctx context.Context, key string, at, forgetBefore time.Time, wait func(Attempts) time.Duration) (time.Duration, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	// The row has to exist for concurrent reservations of a new key to queue
+	// up behind the same lock.
+	err = qtx.CreateLoginAttempts(ctx, database.CreateLoginAttemptsParams{
+		Key:           key,
+		LastFailureAt: at,
+	})
+	if err != nil {
+		return 0, err
+	}
+	row, err := qtx.LockLoginAttempts(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	attempts := Attempts{Failures: int(row.Failures), LastFailureAt: row.LastFailureAt}
+	if attempts.LastFailureAt.Before(forgetBefore) {
+		attempts.Failures = 0
+	}
+	if d := wait(attempts); d > 0 {
+		return d, nil
+	}
+
+	_, err = qtx.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:          key,
+		FailedAt:     at,
+		ForgetBefore: forgetBefore,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return 0, tx.Commit()
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	return s.db.ReleaseLoginFailure(ctx, key)
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.db.ResetLoginAttempts(ctx, key)
+}
@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer delivers email through an SMTP relay, authenticating with PLAIN
+// auth when a username is set.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	from := m.From
+	if addr, err := mail.ParseAddress(m.From); err == nil {
+		from = addr.Address
+	}
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, from, []string{msg.To}, format(m.From, msg, time.Now()))
+}
+
+// FileMailer writes every message to w instead of delivering it. It stands in
+// for SMTP in development (pointed at stdout or a file) and in tests.
+type FileMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	From string
+}
+
+func NewFileMailer(w io.Writer, from string) *FileMailer {
+	return &FileMailer{w: w, From: from}
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.w.Write(append(format(m.From, msg, time.Now()), '\n'))
+	return err
+}
+
+// format renders msg as an RFC 5322 message.
+func format(from string, msg Message, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// headerValue strips line breaks so user input cannot inject headers.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
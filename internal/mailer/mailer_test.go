@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewFileMailer(&buf, "Chirpy <no-reply@chirpy.local>")
+
+	err := m.Send(context.Background(), Message{
+		To:      "walt@breakingbad.com",
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error sending message: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"From: Chirpy <no-reply@chirpy.local>\r\n",
+		"To: walt@breakingbad.com\r\n",
+		"Subject: Reset your password\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestFormat_StripsHeaderInjection(t *testing.T) {
+	out := string(format("a@chirpy.local", Message{
+		To:      "walt@breakingbad.com\r\nBcc: jesse@breakingbad.com",
+		Subject: "hi",
+	}, time.Now()))
+
+	if strings.Contains(out, "\r\nBcc:") {
+		t.Errorf("expected injected header to be stripped, got %q", out)
+	}
+}
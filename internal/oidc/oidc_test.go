@@ -0,0 +1,319 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "chirpy"
+const testClientSecret = "s3cret"
+const testRedirectURL = "http://localhost:8080/api/oauth/stub/callback"
+
+// stubProvider is a minimal in-process OpenID Connect provider. It issues a
+// code for every authorization request and an ID token for the user set on
+// it when the code is redeemed with the right PKCE verifier.
+type stubProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu        sync.Mutex
+	codes     map[string]stubGrant
+	claims    jwt.MapClaims
+	jwksCalls int
+}
+
+type stubGrant struct {
+	challenge string
+	nonce     string
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating RSA key: %v", err)
+	}
+
+	stub := &stubProvider{t: t, key: key, kid: "stub-1", codes: map[string]stubGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Metadata{
+			Issuer:                stub.server.URL,
+			AuthorizationEndpoint: stub.server.URL + "/authorize",
+			TokenEndpoint:         stub.server.URL + "/token",
+			JWKSURI:               stub.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.jwksCalls++
+		_ = json.NewEncoder(w).Encode(jwks{Keys: []jwk{{
+			Kty: "RSA",
+			Kid: stub.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(stub.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(stub.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", stub.handleToken)
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+// authorize stands in for the user approving the login at authURL and
+// returns the code the provider would redirect back with.
+func (s *stubProvider) authorize(authURL string) string {
+	s.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		s.t.Fatalf("unexpected error parsing auth URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirectURL {
+		s.t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := "code-" + q.Get("state")
+	s.codes[code] = stubGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code
+}
+
+func (s *stubProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != testClientID || secret != testClientSecret {
+		w.WriteHeader(401)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+		return
+	}
+
+	s.mu.Lock()
+	grant, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	claims := jwt.MapClaims{}
+	for k, v := range s.claims {
+		claims[k] = v
+	}
+	s.mu.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		CodeChallenge(r.PostFormValue("code_verifier")) != grant.challenge {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = grant.nonce
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		s.t.Fatalf("unexpected error signing ID token: %v", err)
+	}
+
+	_ = json.NewEncoder(w).Encode(Token{AccessToken: "access", TokenType: "Bearer", IDToken: idToken, ExpiresIn: 3600})
+}
+
+func (s *stubProvider) setClaims(claims jwt.MapClaims) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = jwt.MapClaims{
+		"iss": s.server.URL,
+		"aud": testClientID,
+		"sub": "user-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range claims {
+		s.claims[k] = v
+	}
+}
+
+func (s *stubProvider) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       s.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	}, s.server.Client())
+}
+
+// login runs the whole flow and returns the verified claims.
+func login(t *testing.T, stub *stubProvider, p *Provider) (*IDTokenClaims, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatalf("unexpected error making verifier: %v", err)
+	}
+	nonce, err := NewNonce()
+	if err != nil {
+		t.Fatalf("unexpected error making nonce: %v", err)
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, "state", nonce, CodeChallenge(verifier))
+	if err != nil {
+		t.Fatalf("unexpected error building auth URL: %v", err)
+	}
+	code := stub.authorize(authURL)
+
+	token, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("unexpected error exchanging code: %v", err)
+	}
+	return p.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+func TestProvider_Login(t *testing.T) {
+	stub := newStubProvider(t)
+	stub.setClaims(jwt.MapClaims{"email": "walt@breakingbad.com", "email_verified": true})
+
+	claims, err := login(t, stub, stub.provider())
+	if err != nil {
+		t.Fatalf("unexpected error verifying ID token: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "walt@breakingbad.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestProvider_ExchangeRequiresVerifier(t *testing.T) {
+	stub := newStubProvider(t)
+	stub.setClaims(nil)
+	p := stub.provider()
+	ctx := context.Background()
+
+	verifier, _ := NewCodeVerifier()
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", CodeChallenge(verifier))
+	if err != nil {
+		t.Fatalf("unexpected error building auth URL: %v", err)
+	}
+	code := stub.authorize(authURL)
+
+	other, _ := NewCodeVerifier()
+	_, err = p.Exchange(ctx, code, other)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("expected invalid_grant exchanging with the wrong verifier, got %v", err)
+	}
+}
+
+func TestProvider_RejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"wrong audience", jwt.MapClaims{"aud": "someone-else"}},
+		{"wrong issuer", jwt.MapClaims{"iss": "https://evil.example"}},
+		{"expired", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}},
+		{"no expiry", jwt.MapClaims{"exp": nil}},
+		{"wrong nonce", jwt.MapClaims{"nonce": "replayed"}},
+		{"no subject", jwt.MapClaims{"sub": ""}},
+		{"foreign azp", jwt.MapClaims{"aud": []string{testClientID, "other"}, "azp": "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubProvider(t)
+			stub.setClaims(tt.claims)
+			if exp, ok := tt.claims["exp"]; ok && exp == nil {
+				delete(stub.claims, "exp")
+			}
+
+			if _, err := login(t, stub, stub.provider()); err == nil {
+				t.Error("expected error verifying ID token, got nil")
+			}
+		})
+	}
+}
+
+func TestProvider_RefetchesKeysOnRotation(t *testing.T) {
+	stub := newStubProvider(t)
+	stub.setClaims(nil)
+	p := stub.provider()
+
+	if _, err := login(t, stub, p); err != nil {
+		t.Fatalf("unexpected error verifying ID token: %v", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating RSA key: %v", err)
+	}
+	stub.mu.Lock()
+	stub.key, stub.kid = key, "stub-2"
+	stub.mu.Unlock()
+
+	if _, err := login(t, stub, p); err == nil {
+		t.Error("expected unknown kid to be rejected inside the refresh interval, got nil")
+	}
+
+	p.now = func() time.Time { return time.Now().Add(2 * jwksRefreshInterval) }
+	if _, err := login(t, stub, p); err != nil {
+		t.Errorf("expected rotated key to be fetched, got %v", err)
+	}
+	if stub.jwksCalls != 2 {
+		t.Errorf("expected 2 JWKS fetches, got %d", stub.jwksCalls)
+	}
+}
+
+func TestProvider_RejectsHMACWithPublicKey(t *testing.T) {
+	stub := newStubProvider(t)
+	p := stub.provider()
+	ctx := context.Background()
+
+	if _, err := p.Discover(ctx); err != nil {
+		t.Fatalf("unexpected error discovering provider: %v", err)
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": stub.server.URL, "aud": testClientID, "sub": "admin",
+		"exp": time.Now().Add(time.Hour).Unix(), "nonce": "nonce",
+	})
+	forged.Header["kid"] = stub.kid
+	token, err := forged.SignedString(stub.key.N.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error signing JWT: %v", err)
+	}
+
+	if _, err := p.VerifyIDToken(ctx, token, "nonce"); err == nil {
+		t.Error("expected error verifying an HS256 ID token, got nil")
+	}
+}
+
+func TestDiscover_RejectsIssuerMismatch(t *testing.T) {
+	stub := newStubProvider(t)
+	p := NewProvider(Config{Issuer: stub.server.URL + "/other", ClientID: testClientID}, stub.server.Client())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /other/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Metadata{Issuer: stub.server.URL})
+	})
+	stub.server.Config.Handler = mux
+
+	if _, err := p.Discover(context.Background()); err == nil {
+		t.Error("expected error for a discovery document with another issuer, got nil")
+	}
+}
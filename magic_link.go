@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/mailer"
+)
+
+const magicLinkTokenDuration = 15 * time.Minute
+
+func (cfg *apiConfig) handleRequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	// Unknown addresses get the same response so accounts cannot be enumerated.
+	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJson(w, 202, nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "error fetching user")
+		return
+	}
+
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		respondWithError(w, 500, "cannot generate login token")
+		return
+	}
+
+	err = cfg.db.CreateMagicLinkToken(r.Context(), database.CreateMagicLinkTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(magicLinkTokenDuration),
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot create login token")
+		return
+	}
+
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Sign in to Chirpy",
+		Body: fmt.Sprintf(
+			"Someone asked to sign in to your Chirpy account.\n\n"+
+				"Send this token to POST /api/login/magic/verify within %d minutes:\n\n%s\n\n"+
+				"The token works once. If it wasn't you, you can ignore this email.",
+			int(magicLinkTokenDuration.Minutes()), token,
+		),
+	})
+
+	respondWithJson(w, 202, nil)
+}
+
+func (cfg *apiConfig) handleVerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot verify login token")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	magicToken, err := qtx.ConsumeMagicLinkToken(r.Context(), auth.HashToken(params.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 401, "invalid or expired token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot verify login token")
+		return
+	}
+	err = qtx.InvalidateMagicLinkTokensForUser(r.Context(), magicToken.UserID)
+	if err != nil {
+		respondWithError(w, 500, "cannot verify login token")
+		return
+	}
+
+	user, err := qtx.GetUser(r.Context(), magicToken.UserID)
+	if err != nil {
+		respondWithError(w, 500, "error fetching user")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot verify login token")
+		return
+	}
+
+	cfg.completeLogin(w, r, user, "magic_link")
+}
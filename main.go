@@ -1,44 +1,79 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"os"
-	"sort"
+	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/chirps"
 	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/lockout"
+	"github.com/sidis405/chirpy/internal/mailer"
+	"github.com/sidis405/chirpy/internal/oidc"
 )
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 )
 
 const port = "8080"
+const filePathRoot = "."
+
+const accessTokenDuration = time.Hour
+const mfaTokenDuration = 5 * time.Minute
+const refreshTokenDuration = time.Duration(24*60) * time.Hour
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	conn           *sql.DB
 	db             *database.Queries
-	secret         string
+	keys           *auth.KeySet
+	mfaKey         []byte
+	passwords      *auth.PasswordHasher
+	passwordPolicy auth.PasswordPolicy
+	chirpPolicy    *chirps.Policy
+	chirpEdits     chirpEditWindows
+	mailer         mailer.Mailer
+	accountLimiter *lockout.Limiter
+	ipLimiter      *lockout.Limiter
+	oidcProviders  map[string]*oidc.Provider
+	exportDir      string
+	exportTTL      time.Duration
+	exportQueued   chan struct{}
+	baseURL        string
 	polkaApiKey    string
+
+	requireVerifiedEmail bool
+	legacyChirpListing   bool
 }
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
-	Email        string    `json:"email"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
+	ID            uuid.UUID `json:"id"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	PendingEmail  string    `json:"pending_email,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Token         string    `json:"token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
 }
 
 type Chirp struct {
@@ -47,6 +82,7 @@ type Chirp struct {
 	UserID    uuid.UUID `json:"user_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Edited    bool      `json:"edited"`
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -67,6 +103,164 @@ func (cfg *apiConfig) resetHandler() {
 	cfg.fileserverHits = atomic.Int32{}
 }
 
+// createRefreshToken issues a refresh token for the request's client. A nil
+// parent starts a new session (token family), as happens on login; otherwise
+// the token rotates parent and inherits its session.
+// Only the token digest is stored; the plaintext is returned to the client
+// along with the session ID.
+func (cfg *apiConfig) createRefreshToken(r *http.Request, q *database.Queries, userID uuid.UUID, parent *database.RefreshToken) (string, uuid.UUID, error) {
+	refreshTokenString, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	params := database.CreateRefreshTokenParams{
+		TokenHash:        auth.HashToken(refreshTokenString),
+		UserID:           userID,
+		ExpiresAt:        time.Now().Add(refreshTokenDuration),
+		FamilyID:         uuid.New(),
+		UserAgent:        r.UserAgent(),
+		IpAddress:        clientIP(r),
+		SessionStartedAt: time.Now(),
+	}
+	if parent != nil {
+		params.FamilyID = parent.FamilyID
+		params.ParentTokenHash = sql.NullString{String: parent.TokenHash, Valid: true}
+		params.SessionStartedAt = parent.SessionStartedAt
+	}
+
+	_, err = q.CreateRefreshToken(r.Context(), params)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return refreshTokenString, params.FamilyID, nil
+}
+
+// completeLogin finishes a login once the first factor, named by method, has
+// been verified. Users with two-factor authentication get an mfa_required
+// challenge, everyone else gets their access and refresh tokens.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User, method string) {
+	totp, err := cfg.db.GetTOTP(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "cannot check two-factor authentication")
+		return
+	}
+
+	if err == nil && totp.ConfirmedAt.Valid {
+		type mfaChallenge struct {
+			MFARequired bool   `json:"mfa_required"`
+			MFAToken    string `json:"mfa_token"`
+		}
+
+		mfaToken, err := cfg.keys.MakeMFAToken(user.ID, mfaTokenDuration)
+		if err != nil {
+			respondWithError(w, 500, "cannot generate mfa token")
+			return
+		}
+
+		cfg.audit(r, auditEvent{
+			Type:     auditLoginMFAChallenged,
+			UserID:   user.ID,
+			Metadata: map[string]any{"method": method},
+		})
+		respondWithJson(w, 200, mfaChallenge{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
+
+	cfg.respondWithTokens(w, r, user, method)
+}
+
+// respondWithTokens starts a new session for user, who logged in with method,
+// and responds with the user along with its access and refresh tokens.
+// Logging in also restores an account that was scheduled for deletion.
+func (cfg *apiConfig) respondWithTokens(w http.ResponseWriter, r *http.Request, user database.User, method string) {
+	cfg.cancelAccountDeletion(r, user)
+
+	refreshToken, sessionID, err := cfg.createRefreshToken(r, cfg.db, user.ID, nil)
+
+	if err != nil {
+		respondWithError(w, 500, "cannot create refresh token")
+		return
+	}
+
+	token, err := cfg.keys.MakeSessionJWT(user.ID, user.Role, sessionID, accessTokenDuration)
+
+	if err != nil {
+		respondWithError(w, 500, "cannot generate access token")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditLoginSucceeded,
+		UserID:   user.ID,
+		Metadata: map[string]any{"method": method},
+	})
+
+	userResponse := dbUserToUserStruct(user)
+	userResponse.Token = token
+	userResponse.RefreshToken = refreshToken
+	respondWithJson(w, 200, userResponse)
+}
+
+// revokeReusedRefreshToken kills the whole token family when an already
+// rotated (revoked) refresh token is presented again, as that means it leaked.
+func (cfg *apiConfig) revokeReusedRefreshToken(r *http.Request, token string) {
+	ctx := r.Context()
+	refreshToken, err := cfg.db.FindRefreshToken(ctx, auth.HashToken(token))
+	if err != nil || !refreshToken.RevokedAt.Valid {
+		return
+	}
+
+	log.Printf("Refresh token reuse detected for user %s, revoking family %s", refreshToken.UserID, refreshToken.FamilyID)
+	err = cfg.db.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+	if err != nil {
+		log.Printf("Error revoking refresh token family %s: %s", refreshToken.FamilyID, err)
+	}
+	cfg.audit(r, auditEvent{
+		Type:     auditRefreshTokenReused,
+		UserID:   refreshToken.UserID,
+		Metadata: map[string]any{"session_id": refreshToken.FamilyID},
+	})
+}
+
+// checkPasswordPolicy rejects a password the policy does not allow with a
+// 422 listing every violation.
+func (cfg *apiConfig) checkPasswordPolicy(w http.ResponseWriter, password, email string) bool {
+	violations := cfg.passwordPolicy.Check(password, email)
+	if len(violations) == 0 {
+		return true
+	}
+
+	type policyError struct {
+		Error      string                   `json:"error"`
+		Violations []auth.PasswordViolation `json:"violations"`
+	}
+
+	respondWithJson(w, 422, policyError{
+		Error:      "password does not meet the password policy",
+		Violations: violations,
+	})
+	return false
+}
+
+// rehashPassword replaces a user's password hash with one made by the
+// preferred hasher. Failures only mean the upgrade is retried on next login.
+func (cfg *apiConfig) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := cfg.passwords.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password for user %s: %s", userID, err)
+		return
+	}
+	err = cfg.db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		log.Printf("Error saving rehashed password for user %s: %s", userID, err)
+	}
+}
+
 func main() {
 	_ = godotenv.Load()
 
@@ -76,20 +270,180 @@ func main() {
 		panic(err)
 	}
 
+	if len(os.Args) > 1 {
+		err = runCommand(context.Background(), database.New(db), os.Args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	keys := auth.NewHMACKeySet(os.Getenv("SECRET"))
+	if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+		rotationWindow := 24 * time.Hour
+		if window := os.Getenv("JWT_ROTATION_WINDOW"); window != "" {
+			rotationWindow, err = time.ParseDuration(window)
+			if err != nil {
+				log.Fatalf("invalid JWT_ROTATION_WINDOW: %s", err)
+			}
+		}
+		keys, err = auth.LoadKeySet(keysDir, rotationWindow)
+		if err != nil {
+			log.Fatalf("cannot load JWT signing keys: %s", err)
+		}
+	}
+
+	var mfaKey []byte
+	if encodedKey := os.Getenv("MFA_ENCRYPTION_KEY"); encodedKey != "" {
+		mfaKey, err = base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil || len(mfaKey) != 32 {
+			log.Fatal("MFA_ENCRYPTION_KEY must be 32 base64 encoded bytes")
+		}
+	}
+
+	argon2Params := auth.DefaultArgon2idParams
+	argon2Params.Memory = uint32(envInt("ARGON2_MEMORY_KIB", int(argon2Params.Memory)))
+	argon2Params.Iterations = uint32(envInt("ARGON2_ITERATIONS", int(argon2Params.Iterations)))
+	argon2Params.Parallelism = uint8(envInt("ARGON2_PARALLELISM", int(argon2Params.Parallelism)))
+	argon2Hasher := auth.NewArgon2idHasher(argon2Params)
+	bcryptHasher := auth.NewBcryptHasher(envInt("BCRYPT_COST", auth.DefaultBcryptCost))
+	var passwords *auth.PasswordHasher
+	switch os.Getenv("PASSWORD_HASHER") {
+	case "", "argon2id":
+		passwords = auth.NewPasswordHasher(argon2Hasher, bcryptHasher)
+	case "bcrypt":
+		passwords = auth.NewPasswordHasher(bcryptHasher, argon2Hasher)
+	default:
+		log.Fatal("PASSWORD_HASHER must be argon2id or bcrypt")
+	}
+
+	passwordPolicy := auth.PasswordPolicy{
+		MinLength: envInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength: envInt("PASSWORD_MAX_LENGTH", 64),
+	}
+	if breachedFile := os.Getenv("BREACHED_PASSWORDS_FILE"); breachedFile != "" {
+		passwordPolicy.Breached, err = auth.LoadBreachedPasswords(breachedFile)
+		if err != nil {
+			log.Fatalf("cannot load BREACHED_PASSWORDS_FILE: %s", err)
+		}
+		log.Printf("Loaded %d breached password hashes", passwordPolicy.Breached.Len())
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "Chirpy <no-reply@chirpy.local>"
+	}
+	var mail mailer.Mailer
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		smtpPort := os.Getenv("SMTP_PORT")
+		if smtpPort == "" {
+			smtpPort = "587"
+		}
+		mail = mailer.NewSMTPMailer(os.Getenv("SMTP_HOST"), smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), mailFrom)
+	case "file":
+		mailFile, err := os.OpenFile(os.Getenv("MAILER_FILE"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatalf("cannot open MAILER_FILE: %s", err)
+		}
+		mail = mailer.NewFileMailer(mailFile, mailFrom)
+	default:
+		mail = mailer.NewFileMailer(os.Stdout, mailFrom)
+	}
+
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + port
+	}
+
+	// Exports must not live under the working directory, which is served
+	// publicly at /app/.
+	exportDir := os.Getenv("DATA_EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = filepath.Join(os.TempDir(), "chirpy-exports")
+	}
+	err = os.MkdirAll(exportDir, 0700)
+	if err != nil {
+		log.Fatalf("cannot create DATA_EXPORT_DIR: %s", err)
+	}
+
+	var attemptStore lockout.Store = lockout.NewMemoryStore()
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "postgres" {
+		attemptStore = lockout.NewPostgresStore(db)
+	}
+	lockoutDuration := envDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	accountLimiter := lockout.NewLimiter(attemptStore, lockout.Policy{
+		MaxFailures:     envInt("LOGIN_MAX_FAILURES", 5),
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutDuration: lockoutDuration,
+		Window:          time.Hour,
+	})
+	ipLimiter := lockout.NewLimiter(attemptStore, lockout.Policy{
+		MaxFailures:     envInt("LOGIN_IP_MAX_FAILURES", 50),
+		BaseDelay:       100 * time.Millisecond,
+		MaxDelay:        5 * time.Second,
+		LockoutDuration: lockoutDuration,
+		Window:          time.Hour,
+	})
+
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
+		conn:           db,
 		db:             database.New(db),
-		secret:         os.Getenv("SECRET"),
+		keys:           keys,
+		mfaKey:         mfaKey,
+		passwords:      passwords,
+		passwordPolicy: passwordPolicy,
+		chirpPolicy:    chirps.NewPolicy(envInt("CHIRP_MAX_LENGTH", chirps.DefaultMaxLength)),
+		chirpEdits: chirpEditWindows{
+			standard:  envDuration("CHIRP_EDIT_WINDOW", 15*time.Minute),
+			chirpyRed: envDuration("CHIRP_EDIT_WINDOW_RED", time.Hour),
+		},
+		mailer:         mail,
+		accountLimiter: accountLimiter,
+		ipLimiter:      ipLimiter,
+		oidcProviders:  loadOIDCProviders(strings.TrimSuffix(baseURL, "/")),
+		exportDir:      exportDir,
+		exportTTL:      envDuration("DATA_EXPORT_TTL", 7*24*time.Hour),
+		exportQueued:   make(chan struct{}, 1),
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
 		polkaApiKey:    os.Getenv("POLKA_KEY"),
+
+		requireVerifiedEmail: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+		legacyChirpListing:   os.Getenv("LEGACY_CHIRP_LISTING") == "true",
 	}
 
+	err = apiCfg.reloadContentFilters(context.Background())
+	if err != nil {
+		log.Fatalf("cannot load content filters: %s", err)
+	}
+	go apiCfg.runContentFilterReload(context.Background(), envDuration("CONTENT_FILTER_RELOAD_INTERVAL", 30*time.Second))
+	go runAccountPurge(context.Background(), apiCfg.db, envDuration("ACCOUNT_PURGE_INTERVAL", time.Hour))
+	go apiCfg.runDataExports(context.Background())
+
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: apiCfg.routes(),
+	}
+
+	log.Printf("Serving files from %s on port: %s\n", filePathRoot, port)
+	log.Fatal(srv.ListenAndServe())
+}
+
+// routes registers every endpoint of the API, and the static files of the
+// working directory under /app/.
+func (cfg *apiConfig) routes() *http.ServeMux {
 	mux := http.NewServeMux()
-	filePathRoot := http.Dir(".")
-	fs := http.FileServer(filePathRoot)
-	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", fs)))
+	fs := http.FileServer(http.Dir(filePathRoot))
+	mux.Handle("/app/", cfg.middlewareMetricsInc(http.StripPrefix("/app/", fs)))
 
 	mux.HandleFunc("GET /api/healthz", handleHealthz)
-	mux.HandleFunc("POST /api/validate_chirp", handleValidateChirp)
+	mux.HandleFunc("GET /.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		respondWithJson(w, 200, cfg.keys.JWKS())
+	})
+	mux.HandleFunc("POST /api/validate_chirp", cfg.handleValidateChirp)
 
 	mux.HandleFunc("POST /api/login", func(w http.ResponseWriter, r *http.Request) {
 		type parameters struct {
@@ -105,75 +459,130 @@ func main() {
 			return
 		}
 
-		user, err := apiCfg.db.GetUserByEmail(r.Context(), params.Email)
+		accountKey := "account:" + strings.ToLower(params.Email)
+		ipKey := "ip:" + clientIP(r)
+		if !cfg.throttleLoginAttempt(w, r, accountKey, ipKey) {
+			return
+		}
+
+		user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.audit(r, auditEvent{
+				Type:     auditLoginFailed,
+				Metadata: map[string]any{"method": "password", "email": params.Email, "reason": "unknown_email"},
+			})
+			respondWithError(w, 401, "unauthorized")
+			return
+		}
 		if err != nil {
 			respondWithError(w, 500, "error fetching user")
 			return
 		}
-		matchesPwd, err := auth.CheckPasswordHash(params.Password, user.HashedPassword)
-		if err != nil {
+		matchesPwd, needsRehash, err := cfg.passwords.Verify(params.Password, user.HashedPassword)
+		if err != nil && !errors.Is(err, auth.ErrUnknownHashFormat) {
 			respondWithError(w, 500, "cannot check pwd")
 			return
 		}
 
 		if !matchesPwd {
+			cfg.audit(r, auditEvent{
+				Type:     auditLoginFailed,
+				UserID:   user.ID,
+				Metadata: map[string]any{"method": "password", "reason": "wrong_password"},
+			})
 			respondWithError(w, 401, "unauthorized")
 			return
 		}
 
-		token, err := auth.MakeJWT(user.ID, apiCfg.secret)
+		cfg.finishLoginAttempt(r, accountKey, ipKey)
 
-		if err != nil {
-			respondWithError(w, 401, fmt.Sprintf("%q", err))
+		if needsRehash {
+			cfg.rehashPassword(r.Context(), user.ID, params.Password)
 		}
 
-		refreshTokenString, err := auth.MakeRefreshToken()
-
-		if err != nil {
-			respondWithError(w, 401, fmt.Sprintf("%q", err))
-		}
-
-		refreshToken, err := apiCfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-			Token:     refreshTokenString,
-			UserID:    user.ID,
-			ExpiresAt: time.Now().Add(time.Duration(24*60) * time.Hour),
-		})
-
-		respondWithJson(w, 200, User{
-			ID:           user.ID,
-			Email:        user.Email,
-			CreatedAt:    user.CreatedAt,
-			UpdatedAt:    user.UpdatedAt,
-			Token:        token,
-			RefreshToken: refreshToken.Token,
-			IsChirpyRed:  user.IsChirpyRed,
-		})
+		cfg.completeLogin(w, r, user, "password")
 		return
 	})
+	mux.HandleFunc("POST /api/login/magic", cfg.handleRequestMagicLink)
+	mux.HandleFunc("POST /api/login/magic/verify", cfg.handleVerifyMagicLink)
+	mux.HandleFunc("POST /api/login/mfa", cfg.handleLoginMFA)
+	mux.HandleFunc("GET /api/oauth/{provider}/start", cfg.handleStartOIDCLogin)
+	mux.HandleFunc("GET /api/oauth/{provider}/callback", cfg.handleOIDCCallback)
+	mux.HandleFunc("GET /oauth/authorize", cfg.handleAuthorizePage)
+	mux.HandleFunc("POST /oauth/authorize", cfg.handleAuthorizeDecision)
+	mux.HandleFunc("POST /oauth/token", cfg.handleOAuthToken)
+	mux.HandleFunc("POST /oauth/revoke", cfg.handleOAuthRevoke)
+	mux.HandleFunc("POST /oauth/introspect", cfg.handleOAuthIntrospect)
+	mux.HandleFunc("POST /api/mfa/totp", cfg.handleEnrollTOTP)
+	mux.HandleFunc("POST /api/mfa/totp/confirm", cfg.handleConfirmTOTP)
+	mux.HandleFunc("DELETE /api/mfa/totp", cfg.handleDisableTOTP)
+	mux.HandleFunc("POST /api/password/forgot", cfg.handleForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", cfg.handleResetPassword)
 	mux.HandleFunc("POST /api/refresh", func(w http.ResponseWriter, r *http.Request) {
 		token, err := auth.GetBearerToken(r.Header)
 		if err != nil {
 			respondWithError(w, 400, "no token found in header")
 			return
 		}
-		refreshToken, err := apiCfg.db.GetRefreshToken(r.Context(), token)
-
+		tx, err := cfg.conn.BeginTx(r.Context(), nil)
 		if err != nil {
+			respondWithError(w, 500, "cannot rotate refresh token")
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.db.WithTx(tx)
+
+		refreshToken, err := qtx.ConsumeRefreshToken(r.Context(), auth.HashToken(token))
+
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = tx.Rollback()
+			cfg.revokeReusedRefreshToken(r, token)
 			respondWithError(w, 401, "unauthorized")
 			return
 		}
+		if err != nil {
+			respondWithError(w, 500, "cannot rotate refresh token")
+			return
+		}
 
 		type tokenResponse struct {
-			Token string `json:"token"`
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+
+		newRefreshToken, sessionID, err := cfg.createRefreshToken(r, qtx, refreshToken.UserID, &refreshToken)
+
+		if err != nil {
+			respondWithError(w, 500, "cannot rotate refresh token")
+			return
+		}
+
+		user, err := qtx.GetUser(r.Context(), refreshToken.UserID)
+
+		if err != nil {
+			respondWithError(w, 500, "cannot rotate refresh token")
+			return
 		}
 
-		accessToken, err := auth.MakeJWT(refreshToken.UserID, apiCfg.secret)
+		accessToken, err := cfg.keys.MakeSessionJWT(user.ID, user.Role, sessionID, accessTokenDuration)
 
 		if err != nil {
 			respondWithError(w, 500, "cannot generate new access token")
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			respondWithError(w, 500, "cannot rotate refresh token")
+			return
 		}
 
-		respondWithJson(w, 200, tokenResponse{Token: accessToken})
+		cfg.audit(r, auditEvent{
+			Type:     auditTokenRefreshed,
+			UserID:   user.ID,
+			Metadata: map[string]any{"session_id": refreshToken.FamilyID},
+		})
+		respondWithJson(w, 200, tokenResponse{Token: accessToken, RefreshToken: newRefreshToken})
 		return
 	})
 	mux.HandleFunc("POST /api/revoke", func(w http.ResponseWriter, r *http.Request) {
@@ -182,23 +591,39 @@ func main() {
 			respondWithError(w, 400, "no token found in header")
 			return
 		}
-		refreshToken, err := apiCfg.db.GetRefreshToken(r.Context(), token)
+		refreshToken, err := cfg.db.GetRefreshToken(r.Context(), auth.HashToken(token))
 
 		if err != nil {
 			respondWithError(w, 401, "unauthorized")
 			return
 		}
 
-		err = apiCfg.db.RevokeRefreshToken(r.Context(), refreshToken.Token)
+		err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken.TokenHash)
 
 		if err != nil {
 			respondWithError(w, 500, "cannot revoke token")
 			return
 		}
 
+		cfg.audit(r, auditEvent{
+			Type:     auditTokenRevoked,
+			UserID:   refreshToken.UserID,
+			Metadata: map[string]any{"session_id": refreshToken.FamilyID},
+		})
 		respondWithJson(w, 204, nil)
 		return
 	})
+	mux.HandleFunc("GET /api/sessions", cfg.handleListSessions)
+	mux.HandleFunc("DELETE /api/sessions", cfg.handleRevokeAllSessions)
+	mux.HandleFunc("DELETE /api/sessions/{id}", cfg.handleRevokeSession)
+	mux.HandleFunc("GET /api/tokens", cfg.handleListTokens)
+	mux.HandleFunc("POST /api/tokens", cfg.handleCreateToken)
+	mux.HandleFunc("DELETE /api/tokens/{id}", cfg.handleRevokeToken)
+	mux.HandleFunc("GET /api/oauth/clients", cfg.handleListOAuthClients)
+	mux.HandleFunc("POST /api/oauth/clients", cfg.handleCreateOAuthClient)
+	mux.HandleFunc("DELETE /api/oauth/clients/{id}", cfg.handleDeleteOAuthClient)
+	mux.HandleFunc("GET /api/oauth/apps", cfg.handleListAuthorizedApps)
+	mux.HandleFunc("DELETE /api/oauth/apps/{id}", cfg.handleRevokeAuthorizedApp)
 	mux.HandleFunc("POST /api/users", func(w http.ResponseWriter, r *http.Request) {
 		type parameters struct {
 			Email    string `json:"email"`
@@ -212,39 +637,41 @@ func main() {
 			return
 		}
 
-		hashedPassword, err := auth.HashPassword(params.Password)
+		if !cfg.checkPasswordPolicy(w, params.Password, params.Email) {
+			return
+		}
+
+		hashedPassword, err := cfg.passwords.Hash(params.Password)
 		if err != nil {
 			respondWithError(w, 500, "hashing error")
 			return
 		}
 
-		user, err := apiCfg.db.CreateUser(r.Context(), database.CreateUserParams{
+		user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 			Email:          params.Email,
 			HashedPassword: hashedPassword,
 		})
 		if err != nil {
 			respondWithError(w, 400, fmt.Sprintf("%q", err))
+			return
 		}
 
-		respondWithJson(w, 201, User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		})
-		return
-	})
-	mux.HandleFunc("PUT /api/users", func(w http.ResponseWriter, r *http.Request) {
-		token, err := auth.GetBearerToken(r.Header)
+		err = cfg.sendVerificationEmail(r.Context(), user.ID, user.Email)
 		if err != nil {
-			respondWithError(w, 401, "missing token")
-			return
+			log.Printf("Error sending verification email to user %s: %s", user.ID, err)
 		}
 
-		userID, err := auth.ValidateJWT(token, apiCfg.secret)
-		if err != nil {
-			respondWithError(w, 401, "invalid token")
+		respondWithJson(w, 201, dbUserToUserStruct(user))
+		return
+	})
+	// PUT /api/users is deprecated in favour of PATCH /api/users/me and kept
+	// for existing clients, which send the email along with the password.
+	// The password no longer changes here: it has to be the current one, and
+	// confirms an email change. New passwords go to POST /api/users/me/password.
+	mux.HandleFunc("PUT /api/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		userID, ok := cfg.requireOwnSession(w, r)
+		if !ok {
 			return
 		}
 
@@ -254,89 +681,59 @@ func main() {
 		}
 		params := parameters{}
 		decoder := json.NewDecoder(r.Body)
-		err = decoder.Decode(&params)
+		err := decoder.Decode(&params)
 		if err != nil {
 			respondWithError(w, 500, "cannot unmarshal data")
 			return
 		}
 
-		hashedPassword, err := auth.HashPassword(params.Password)
+		currentUser, err := cfg.db.GetUser(r.Context(), userID)
 		if err != nil {
-			respondWithError(w, 500, "hashing error")
+			respondWithError(w, 404, "not found")
 			return
 		}
 
-		user, err := apiCfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-			ID:             userID,
-			Email:          params.Email,
-			HashedPassword: hashedPassword,
-		})
-
-		respondWithJson(w, 200, User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		})
-		return
-	})
-
-	mux.HandleFunc("GET /api/chirps", func(w http.ResponseWriter, r *http.Request) {
-		authorIdString := r.URL.Query().Get("author_id")
-		var rawChirps []database.Chirp
-		if authorIdString != "" {
-			authorId, err := uuid.Parse(authorIdString)
-			if err != nil {
-				respondWithError(w, 400, "invalid author id")
-				return
-			}
-			rawChirps, err = apiCfg.db.GetAllChirpsForUser(r.Context(), authorId)
-			if err != nil {
-				respondWithError(w, 500, "cannot fetch chirps")
-				return
-			}
-		} else {
-			rawChirps, err = apiCfg.db.GetAllChirps(r.Context())
-			if err != nil {
-				respondWithError(w, 500, "cannot fetch chirps")
+		if params.Email != currentUser.Email || params.Password != "" {
+			if !cfg.confirmIdentity(w, r, currentUser, params.Password, "change_email") {
 				return
 			}
 		}
-
-		var chirps []Chirp
-
-		for _, chirp := range rawChirps {
-			chirps = append(chirps, dbChirpToChirpStruct(chirp))
+		pendingEmail, ok := cfg.checkEmailChange(w, r, currentUser, params.Email)
+		if !ok {
+			return
 		}
 
-		sortOrder := strings.ToLower(r.URL.Query().Get("sort"))
-
-		switch sortOrder {
-		case "asc":
-			sort.Slice(chirps, func(i, j int) bool {
-				return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-			})
-		case "desc":
-			sort.Slice(chirps, func(i, j int) bool {
-				return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-			})
+		if pendingEmail != "" {
+			err = cfg.startEmailChange(r, currentUser, pendingEmail)
+			if err != nil {
+				respondWithError(w, 500, "cannot send verification email")
+				return
+			}
 		}
 
-		respondWithJson(w, 200, chirps)
-		return
+		userResponse := dbUserToUserStruct(currentUser)
+		userResponse.PendingEmail = pendingEmail
+		respondWithJson(w, 200, userResponse)
 	})
+	mux.HandleFunc("PATCH /api/users/me", cfg.handleUpdateProfile)
+	mux.HandleFunc("POST /api/users/me/password", cfg.handleChangePassword)
+	mux.HandleFunc("DELETE /api/users", cfg.handleDeleteAccount)
+	mux.HandleFunc("GET /api/me/security-events", cfg.handleListSecurityEvents)
+	mux.HandleFunc("POST /api/me/export", cfg.handleRequestDataExport)
+	mux.HandleFunc("GET /api/me/export/{id}", cfg.handleGetDataExport)
+	mux.HandleFunc("GET /api/verify-email", cfg.handleVerifyEmail)
+	mux.HandleFunc("POST /api/verify-email/resend", cfg.handleResendVerificationEmail)
+
+	mux.HandleFunc("GET /api/chirps", cfg.handleListChirps)
+	mux.HandleFunc("GET /api/chirps/search", cfg.handleSearchChirps)
 	mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
 
-		token, err := auth.GetBearerToken(r.Header)
-		if err != nil {
-			respondWithError(w, 401, "missing token")
+		userID, ok := cfg.requireUser(w, r, auth.ScopeChirpsWrite)
+		if !ok {
 			return
 		}
 
-		userID, err := auth.ValidateJWT(token, apiCfg.secret)
-		if err != nil {
-			respondWithError(w, 401, "invalid token")
+		if !cfg.checkCanWriteChirps(w, r, userID) {
 			return
 		}
 
@@ -345,15 +742,27 @@ func main() {
 		}
 		params := parameters{}
 		decoder := json.NewDecoder(r.Body)
-		err = decoder.Decode(&params)
+		err := decoder.Decode(&params)
 		if err != nil {
 			respondWithError(w, 500, "cannot unmarshal data")
 			return
 		}
-		chirp, err := apiCfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-			Body:   params.Body,
+		result := cfg.chirpPolicy.Apply(params.Body)
+		if len(result.Violations) > 0 {
+			cfg.recordFilterHits(r.Context(), userID, uuid.Nil, result.Hits)
+			respondWithChirpViolations(w, result.Violations)
+			return
+		}
+
+		chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:   result.Body,
 			UserID: userID,
 		})
+		if err != nil {
+			respondWithError(w, 500, "cannot create chirp")
+			return
+		}
+		cfg.recordFilterHits(r.Context(), userID, chirp.ID, result.Hits)
 
 		respondWithJson(w, 201, dbChirpToChirpStruct(chirp))
 
@@ -367,7 +776,7 @@ func main() {
 			respondWithError(w, 401, "invalid chirp uuid")
 		}
 
-		chirp, err := apiCfg.db.GetChirp(r.Context(), chirpId)
+		chirp, err := cfg.db.GetChirp(r.Context(), chirpId)
 		if err != nil {
 			respondWithError(w, 404, "not found")
 			return
@@ -376,16 +785,11 @@ func main() {
 		respondWithJson(w, 200, dbChirpToChirpStruct(chirp))
 		return
 	})
+	mux.HandleFunc("PATCH /api/chirps/{id}", cfg.handleEditChirp)
+	mux.HandleFunc("GET /api/chirps/{id}/revisions", cfg.handleListChirpRevisions)
 	mux.HandleFunc("DELETE /api/chirps/{id}", func(w http.ResponseWriter, r *http.Request) {
-		token, err := auth.GetBearerToken(r.Header)
-		if err != nil {
-			respondWithError(w, 401, "missing token")
-			return
-		}
-
-		userID, err := auth.ValidateJWT(token, apiCfg.secret)
-		if err != nil {
-			respondWithError(w, 401, "invalid token")
+		userID, ok := cfg.requireUser(w, r, auth.ScopeChirpsWrite)
+		if !ok {
 			return
 		}
 
@@ -396,7 +800,7 @@ func main() {
 			respondWithError(w, 401, "invalid chirp uuid")
 		}
 
-		chirp, err := apiCfg.db.GetChirp(r.Context(), chirpId)
+		chirp, err := cfg.db.GetChirp(r.Context(), chirpId)
 		if err != nil {
 			respondWithError(w, 404, "not found")
 			return
@@ -407,7 +811,7 @@ func main() {
 			return
 		}
 
-		err = apiCfg.db.DeleteChirp(r.Context(), chirpId)
+		err = cfg.db.DeleteChirp(r.Context(), chirpId)
 
 		if err != nil {
 			respondWithError(w, 500, "cannot delete chirp")
@@ -425,7 +829,7 @@ func main() {
 			return
 		}
 
-		if apiKey != apiCfg.polkaApiKey {
+		if apiKey != cfg.polkaApiKey {
 			respondWithError(w, 401, "invalid apikey")
 			return
 		}
@@ -457,42 +861,53 @@ func main() {
 			return
 		}
 
-		_, err = apiCfg.db.UpgradeUser(r.Context(), userUuid)
+		_, err = cfg.db.UpgradeUser(r.Context(), userUuid)
 		if err != nil {
 			respondWithError(w, 404, "not found")
 			return
 		}
 
+		cfg.audit(r, auditEvent{
+			Type:     auditMembershipUpgraded,
+			UserID:   userUuid,
+			Metadata: map[string]any{"source": "polka"},
+		})
+
 		respondWithJson(w, 204, nil)
 		return
 	})
 
-	mux.HandleFunc("GET /admin/metrics", apiCfg.hitsHandler)
-	mux.HandleFunc("POST /admin/reset", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /admin/metrics", cfg.requireRole(auth.RoleAdmin, cfg.hitsHandler))
+	mux.HandleFunc("GET /admin/audit-events", cfg.requireRole(auth.RoleAdmin, cfg.handleAdminListAuditEvents))
+	mux.HandleFunc("GET /admin/filters", cfg.requireRole(auth.RoleModerator, cfg.handleListContentFilters))
+	mux.HandleFunc("POST /admin/filters", cfg.requireRole(auth.RoleAdmin, cfg.handleCreateContentFilter))
+	mux.HandleFunc("GET /admin/filters/hits", cfg.requireRole(auth.RoleModerator, cfg.handleListContentFilterHits))
+	mux.HandleFunc("GET /admin/filters/{id}", cfg.requireRole(auth.RoleModerator, cfg.handleGetContentFilter))
+	mux.HandleFunc("PUT /admin/filters/{id}", cfg.requireRole(auth.RoleAdmin, cfg.handleUpdateContentFilter))
+	mux.HandleFunc("DELETE /admin/filters/{id}", cfg.requireRole(auth.RoleAdmin, cfg.handleDeleteContentFilter))
+	mux.HandleFunc("POST /admin/users/{id}/unlock", cfg.requireRole(auth.RoleAdmin, cfg.handleUnlockUser))
+	mux.HandleFunc("POST /admin/users/{id}/impersonate", cfg.requireRole(auth.RoleAdmin, cfg.handleImpersonateUser))
+	mux.HandleFunc("PUT /admin/users/{id}/role", cfg.requireRole(auth.RoleAdmin, cfg.handleSetUserRole))
+	mux.HandleFunc("POST /admin/reset", cfg.requireRole(auth.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
 		isDev := os.Getenv("PLATFORM") == "dev"
 
 		if !isDev {
 			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 			w.WriteHeader(403)
 			_, _ = w.Write([]byte("forbidden"))
+			return
 		}
 
-		apiCfg.resetHandler()
-		apiCfg.db.DeleteAllUsers(r.Context())
+		cfg.resetHandler()
+		cfg.db.DeleteAllUsers(r.Context())
 
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.WriteHeader(200)
-		message := fmt.Sprintf("Hits: %d", apiCfg.fileserverHits.Load())
+		message := fmt.Sprintf("Hits: %d", cfg.fileserverHits.Load())
 		_, _ = w.Write([]byte(message))
-	})
-
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
-	}
+	}))
 
-	log.Printf("Serving files from %s on port: %s\n", filePathRoot, port)
-	log.Fatal(srv.ListenAndServe())
+	return mux
 }
 
 func handleHealthz(w http.ResponseWriter, _ *http.Request) {
@@ -501,7 +916,7 @@ func handleHealthz(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("OK"))
 }
 
-func handleValidateChirp(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handleValidateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Body string `json:"body"`
 	}
@@ -514,8 +929,9 @@ func handleValidateChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(params.Body) > 140 {
-		respondWithError(w, 400, "Chirp is too long")
+	result := cfg.chirpPolicy.Apply(params.Body)
+	if len(result.Violations) > 0 {
+		respondWithChirpViolations(w, result.Violations)
 		return
 	}
 
@@ -523,22 +939,355 @@ func handleValidateChirp(w http.ResponseWriter, r *http.Request) {
 		CleanedBody string `json:"cleaned_body"`
 	}
 
-	// redacting stuff
-	words := strings.Split(params.Body, " ")
-	var out []string
-	bad := []string{
-		"kerfuffle", "sharbert", "fornax",
+	respondWithJson(w, 200, okResponse{CleanedBody: result.Body})
+	return
+}
+
+// respondWithChirpViolations rejects a chirp with a 400 listing every
+// violation.
+func respondWithChirpViolations(w http.ResponseWriter, violations []chirps.Violation) {
+	type policyError struct {
+		Error      string             `json:"error"`
+		Violations []chirps.Violation `json:"violations"`
+	}
+
+	respondWithJson(w, 400, policyError{
+		Error:      "chirp is invalid",
+		Violations: violations,
+	})
+}
+
+// checkCanWriteChirps turns away users who have not verified their email
+// address from posting or editing chirps, when REQUIRE_VERIFIED_EMAIL is set.
+func (cfg *apiConfig) checkCanWriteChirps(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	if !cfg.requireVerifiedEmail {
+		return true
 	}
-	for _, word := range words {
-		if slices.Contains(bad, strings.ToLower(word)) {
-			out = append(out, "****")
-		} else {
-			out = append(out, word)
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return false
+	}
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, 403, "email address not verified")
+		return false
+	}
+	return true
+}
+
+// startLoginAttempt counts a login attempt against the account and the IP as
+// a failure before the credentials are checked, so that concurrent guesses
+// are throttled too. It returns how long the caller must wait instead when
+// either key is backing off or locked out. Successful attempts are taken back
+// with finishLoginAttempt; any other outcome stays counted as a failure.
+func (cfg *apiConfig) startLoginAttempt(ctx context.Context, accountKey, ipKey string) (time.Duration, error) {
+	wait, err := cfg.accountLimiter.Attempt(ctx, accountKey)
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+
+	wait, err = cfg.ipLimiter.Attempt(ctx, ipKey)
+	if err != nil || wait > 0 {
+		// The attempt is not going ahead after all.
+		releaseErr := cfg.accountLimiter.Release(ctx, accountKey)
+		if releaseErr != nil {
+			log.Printf("Error releasing login attempt for %s: %s", accountKey, releaseErr)
 		}
+		return wait, err
 	}
+	return 0, nil
+}
 
-	respondWithJson(w, 200, okResponse{CleanedBody: strings.Join(out, " ")})
-	return
+// throttleLoginAttempt starts a login attempt, rejecting the request with a
+// 429 and Retry-After while any of keys is backing off or locked out after
+// failed logins.
+func (cfg *apiConfig) throttleLoginAttempt(w http.ResponseWriter, r *http.Request, accountKey, ipKey string) bool {
+	wait, err := cfg.startLoginAttempt(r.Context(), accountKey, ipKey)
+	if err != nil {
+		respondWithError(w, 500, "cannot check login attempts")
+		return false
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondWithError(w, 429, "too many failed login attempts")
+		return false
+	}
+	return true
+}
+
+// finishLoginAttempt clears the failures of an account that got its
+// credentials right and takes back the attempt counted against the IP.
+func (cfg *apiConfig) finishLoginAttempt(r *http.Request, accountKey, ipKey string) {
+	err := cfg.accountLimiter.Reset(r.Context(), accountKey)
+	if err != nil {
+		log.Printf("Error resetting login attempts for %s: %s", accountKey, err)
+	}
+	err = cfg.ipLimiter.Release(r.Context(), ipKey)
+	if err != nil {
+		log.Printf("Error releasing login attempt for %s: %s", ipKey, err)
+	}
+}
+
+// sendMail delivers msg in the background so response times do not reveal
+// whether an account exists.
+func (cfg *apiConfig) sendMail(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		err := cfg.mailer.Send(ctx, msg)
+		if err != nil {
+			log.Printf("Error sending %q mail: %s", msg.Subject, err)
+		}
+	}()
+}
+
+// requireUser authenticates the request's bearer token: either an access
+// token from a login, which may do anything, or a personal access token or
+// third-party OAuth access token, which must hold scope. On failure it writes
+// the 401 or 403 response itself and returns false.
+func (cfg *apiConfig) requireUser(w http.ResponseWriter, r *http.Request, scope string) (uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, 401, "missing token")
+		return uuid.Nil, false
+	}
+
+	if claims, err := cfg.keys.ValidateOAuthAccessToken(token); err == nil {
+		if !slices.Contains(claims.Scopes(), scope) {
+			respondWithError(w, 403, fmt.Sprintf("token lacks the %s scope", scope))
+			return uuid.Nil, false
+		}
+		if !cfg.oauthGrantActive(r.Context(), claims.GrantID) {
+			respondWithError(w, 401, "invalid token")
+			return uuid.Nil, false
+		}
+		return claims.UserID, true
+	}
+
+	if !auth.IsPersonalAccessToken(token) {
+		return cfg.requireSession(w, r)
+	}
+
+	pat, err := cfg.db.GetPersonalAccessToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return uuid.Nil, false
+	}
+	if !slices.Contains(pat.Scopes, scope) {
+		respondWithError(w, 403, fmt.Sprintf("token lacks the %s scope", scope))
+		return uuid.Nil, false
+	}
+
+	err = cfg.db.TouchPersonalAccessToken(r.Context(), pat.ID)
+	if err != nil {
+		log.Printf("Error recording use of personal access token %s: %s", pat.ID, err)
+	}
+
+	return pat.UserID, true
+}
+
+// requireSession authenticates the request's bearer access token, turning
+// personal access tokens away. It guards endpoints such as token management
+// that a script should never reach.
+func (cfg *apiConfig) requireSession(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := cfg.sessionToken(w, r)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	if claims.Impersonated() {
+		if !cfg.allowImpersonatedRequest(w, r, claims) {
+			return uuid.Nil, false
+		}
+	} else if !cfg.checkSessionActive(w, r, claims) {
+		return uuid.Nil, false
+	}
+
+	return claims.UserID, true
+}
+
+// requireOwnSession is requireSession for endpoints that mint credentials or
+// change security settings, which impersonation tokens must never reach: a
+// time-limited, audited impersonation must not turn into lasting access as
+// the impersonated user.
+func (cfg *apiConfig) requireOwnSession(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := cfg.sessionToken(w, r)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	if claims.Impersonated() {
+		respondWithError(w, 403, "not allowed while impersonating")
+		return uuid.Nil, false
+	}
+	if !cfg.checkSessionActive(w, r, claims) {
+		return uuid.Nil, false
+	}
+
+	return claims.UserID, true
+}
+
+// sessionToken validates the request's bearer access token.
+func (cfg *apiConfig) sessionToken(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, 401, "missing token")
+		return nil, false
+	}
+
+	claims, err := cfg.keys.ValidateJWT(token)
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return nil, false
+	}
+	return claims, true
+}
+
+// checkSessionActive turns away access tokens whose login session has been
+// revoked, so that revoking a session takes effect at once rather than when
+// its access tokens expire.
+func (cfg *apiConfig) checkSessionActive(w http.ResponseWriter, r *http.Request, claims *auth.Claims) bool {
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return false
+	}
+
+	active, err := cfg.db.IsSessionActive(r.Context(), database.IsSessionActiveParams{
+		FamilyID: sessionID,
+		UserID:   claims.UserID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot check session")
+		return false
+	}
+	if !active {
+		respondWithError(w, 401, "session revoked")
+		return false
+	}
+	return true
+}
+
+// allowImpersonatedRequest records a request made with an impersonation token
+// in the audit log and turns away writes when the token is read-only. A
+// request that cannot be audited is refused.
+func (cfg *apiConfig) allowImpersonatedRequest(w http.ResponseWriter, r *http.Request, claims *auth.Claims) bool {
+	actorID, err := uuid.Parse(claims.Act.Sub)
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return false
+	}
+
+	readOnlyViolation := claims.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead
+	err = cfg.recordAuditEvent(r, auditEvent{
+		Type:    auditImpersonatedRequest,
+		ActorID: actorID,
+		UserID:  claims.UserID,
+		Metadata: map[string]any{
+			"method":  r.Method,
+			"path":    r.URL.Path,
+			"allowed": !readOnlyViolation,
+		},
+	})
+	if err != nil {
+		log.Printf("Error auditing impersonated request by %s: %s", actorID, err)
+		respondWithError(w, 500, "cannot audit request")
+		return false
+	}
+
+	if readOnlyViolation {
+		respondWithError(w, 403, "impersonation session is read-only")
+		return false
+	}
+	return true
+}
+
+type claimsContextKey struct{}
+
+// requireRole wraps next so that only access tokens carrying at least role
+// reach it, from an active session of a user who still holds the role:
+// demotions take effect at once, promotions once the user's token is
+// refreshed. Impersonation tokens never pass, whatever the impersonated
+// user's role.
+// The validated claims are available to next via claimsFromContext.
+func (cfg *apiConfig) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, 401, "missing token")
+			return
+		}
+
+		claims, err := cfg.keys.ValidateJWT(token)
+		if err != nil {
+			respondWithError(w, 401, "invalid token")
+			return
+		}
+
+		if !auth.HasRole(claims.Role, role) || claims.Impersonated() {
+			respondWithError(w, 403, "forbidden")
+			return
+		}
+		if !cfg.checkSessionActive(w, r, claims) {
+			return
+		}
+
+		user, err := cfg.db.GetUser(r.Context(), claims.UserID)
+		if err != nil {
+			respondWithError(w, 401, "invalid token")
+			return
+		}
+		if !auth.HasRole(user.Role, role) {
+			respondWithError(w, 403, "forbidden")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+	}
+}
+
+// claimsFromContext returns the claims stored by requireRole.
+func claimsFromContext(ctx context.Context) *auth.Claims {
+	claims, _ := ctx.Value(claimsContextKey{}).(*auth.Claims)
+	return claims
+}
+
+// clientIP returns the address of the peer that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// envInt reads an integer setting, falling back to def when it is unset.
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %s", name, err)
+	}
+	return n
+}
+
+// envDuration reads a duration setting such as "15m", falling back to def
+// when it is unset.
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %s", name, err)
+	}
+	return d
 }
 
 func respondWithError(w http.ResponseWriter, code int, msg string) {
@@ -571,6 +1320,18 @@ func respondWithJson(w http.ResponseWriter, code int, payload interface{}) {
 	_, _ = w.Write(data)
 }
 
+func dbUserToUserStruct(user database.User) User {
+	return User{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Role:          user.Role,
+		IsChirpyRed:   user.IsChirpyRed,
+	}
+}
+
 func dbChirpToChirpStruct(chirp database.Chirp) Chirp {
 	return Chirp{
 		ID:        chirp.ID,
@@ -578,5 +1339,6 @@ func dbChirpToChirpStruct(chirp database.Chirp) Chirp {
 		UserID:    chirp.UserID,
 		CreatedAt: chirp.CreatedAt,
 		UpdatedAt: chirp.UpdatedAt,
+		Edited:    chirp.EditedAt.Valid,
 	}
 }
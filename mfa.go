@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+const totpIssuer = "Chirpy"
+const recoveryCodeCount = 10
+
+func (cfg *apiConfig) handleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	if cfg.mfaKey == nil {
+		respondWithError(w, 503, "two-factor authentication is not configured")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, 500, "cannot generate secret")
+		return
+	}
+	ciphertext, err := auth.Encrypt(cfg.mfaKey, []byte(secret))
+	if err != nil {
+		respondWithError(w, 500, "cannot encrypt secret")
+		return
+	}
+
+	_, err = cfg.db.UpsertTOTP(r.Context(), database.UpsertTOTPParams{
+		UserID:           userID,
+		SecretCiphertext: ciphertext,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 409, "two-factor authentication is already enabled")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot save secret")
+		return
+	}
+
+	type enrollResponse struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+
+	respondWithJson(w, 200, enrollResponse{
+		Secret:     secret,
+		OtpauthURI: auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+func (cfg *apiConfig) handleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	totp, err := cfg.db.GetTOTP(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "two-factor authentication is not enrolled")
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		respondWithError(w, 409, "two-factor authentication is already enabled")
+		return
+	}
+
+	if !cfg.checkSecondFactor(w, r, userID, params.Code, "", "confirm_totp") {
+		return
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, 500, "cannot generate recovery codes")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot enable two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.ConfirmTOTP(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot enable two-factor authentication")
+		return
+	}
+	err = qtx.DeleteRecoveryCodesForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot enable two-factor authentication")
+		return
+	}
+	for _, code := range recoveryCodes {
+		err = qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+			CodeHash: auth.HashToken(code),
+			UserID:   userID,
+		})
+		if err != nil {
+			respondWithError(w, 500, "cannot enable two-factor authentication")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot enable two-factor authentication")
+		return
+	}
+
+	cfg.audit(r, auditEvent{Type: auditMFAEnabled, UserID: userID})
+
+	type confirmResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	respondWithJson(w, 200, confirmResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (cfg *apiConfig) handleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	if !cfg.checkSecondFactor(w, r, userID, params.Code, params.RecoveryCode, "disable_totp") {
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot disable two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.DeleteTOTP(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot disable two-factor authentication")
+		return
+	}
+	err = qtx.DeleteRecoveryCodesForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot disable two-factor authentication")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot disable two-factor authentication")
+		return
+	}
+
+	cfg.audit(r, auditEvent{Type: auditMFADisabled, UserID: userID})
+
+	respondWithJson(w, 204, nil)
+}
+
+func (cfg *apiConfig) handleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	userID, err := cfg.keys.ValidateMFAToken(params.MFAToken)
+	if err != nil {
+		respondWithError(w, 401, "invalid mfa token")
+		return
+	}
+
+	attemptKey := "mfa:" + userID.String()
+	ipKey := "ip:" + clientIP(r)
+	if !cfg.throttleLoginAttempt(w, r, attemptKey, ipKey) {
+		return
+	}
+
+	verified, err := cfg.verifySecondFactor(r.Context(), userID, params.Code, params.RecoveryCode)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "cannot verify code")
+		return
+	}
+	if !verified {
+		cfg.audit(r, auditEvent{
+			Type:     auditLoginFailed,
+			UserID:   userID,
+			Metadata: map[string]any{"method": "mfa", "reason": "wrong_code"},
+		})
+		respondWithError(w, 401, "invalid code")
+		return
+	}
+
+	cfg.finishLoginAttempt(r, attemptKey, ipKey)
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 401, "unauthorized")
+		return
+	}
+
+	cfg.respondWithTokens(w, r, user, "mfa")
+}
+
+// checkSecondFactor verifies a code the signed in user sent to change their
+// two-factor settings. Attempts are throttled with those at the second step
+// of login, so a stolen session cannot be used to guess codes.
+func (cfg *apiConfig) checkSecondFactor(w http.ResponseWriter, r *http.Request, userID uuid.UUID, code, recoveryCode, action string) bool {
+	attemptKey := "mfa:" + userID.String()
+	ipKey := "ip:" + clientIP(r)
+	if !cfg.throttleLoginAttempt(w, r, attemptKey, ipKey) {
+		return false
+	}
+
+	verified, err := cfg.verifySecondFactor(r.Context(), userID, code, recoveryCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "two-factor authentication is not enrolled")
+		return false
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot verify code")
+		return false
+	}
+	if !verified {
+		cfg.audit(r, auditEvent{
+			Type:     auditLoginFailed,
+			UserID:   userID,
+			Metadata: map[string]any{"method": "mfa", "reason": "wrong_code", "action": action},
+		})
+		respondWithError(w, 401, "invalid code")
+		return false
+	}
+
+	cfg.finishLoginAttempt(r, attemptKey, ipKey)
+	return true
+}
+
+// verifySecondFactor checks a TOTP code, or a recovery code when one is given,
+// and burns it so it cannot be replayed.
+func (cfg *apiConfig) verifySecondFactor(ctx context.Context, userID uuid.UUID, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		used, err := cfg.db.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode)),
+			UserID:   userID,
+		})
+		return used == 1, err
+	}
+
+	totp, err := cfg.db.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if cfg.mfaKey == nil {
+		return false, errors.New("two-factor authentication is not configured")
+	}
+	secret, err := auth.Decrypt(cfg.mfaKey, totp.SecretCiphertext)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := auth.ValidateTOTP(string(secret), code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	used, err := cfg.db.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	return used == 1, err
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sidis405/chirpy/internal/auth"
+)
+
+// enrollTOTP turns on two-factor authentication for user and returns the
+// TOTP secret and recovery codes.
+func (ts *testServer) enrollTOTP(user User) (string, []string) {
+	ts.t.Helper()
+	rec := ts.request("POST", "/api/mfa/totp", user.Token, nil)
+	expectStatus(ts.t, rec, 200)
+	enrollment := decodeResponse[struct {
+		Secret string `json:"secret"`
+	}](ts.t, rec)
+
+	code, err := auth.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		ts.t.Fatalf("cannot generate code: %v", err)
+	}
+	rec = ts.request("POST", "/api/mfa/totp/confirm", user.Token, map[string]string{"code": code})
+	expectStatus(ts.t, rec, 200)
+	confirmed := decodeResponse[struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}](ts.t, rec)
+	return enrollment.Secret, confirmed.RecoveryCodes
+}
+
+// wrongTOTPCode returns a code the secret does not accept right now.
+func wrongTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	var valid []string
+	for _, offset := range []time.Duration{-30 * time.Second, 0, 30 * time.Second} {
+		code, err := auth.TOTPCode(secret, time.Now().Add(offset))
+		if err != nil {
+			t.Fatalf("cannot generate code: %v", err)
+		}
+		valid = append(valid, code)
+	}
+	for _, code := range []string{"000000", "111111", "222222", "333333"} {
+		if !slices.Contains(valid, code) {
+			return code
+		}
+	}
+	t.Fatal("cannot find an invalid code")
+	return ""
+}
+
+func TestLoginMFA(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	secret, recoveryCodes := ts.enrollTOTP(user)
+
+	rec := ts.request("POST", "/api/login", "", map[string]string{"email": "walt@breakingbad.com", "password": testPassword})
+	expectStatus(t, rec, 200)
+	challenge := decodeResponse[struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+		Token       string `json:"token"`
+	}](t, rec)
+	if !challenge.MFARequired || challenge.MFAToken == "" || challenge.Token != "" {
+		t.Fatalf("expected a second factor challenge without tokens, got %s", rec.Body)
+	}
+
+	// The MFA token is not an access token.
+	rec = ts.request("GET", "/api/sessions", challenge.MFAToken, nil)
+	expectStatus(t, rec, 401)
+
+	rec = ts.request("POST", "/api/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "code": wrongTOTPCode(t, secret)})
+	expectStatus(t, rec, 401)
+
+	rec = ts.request("POST", "/api/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "recovery_code": recoveryCodes[0]})
+	expectStatus(t, rec, 200)
+	loggedIn := decodeResponse[User](t, rec)
+	if loggedIn.Token == "" || loggedIn.RefreshToken == "" {
+		t.Fatalf("expected tokens, got %s", rec.Body)
+	}
+	rec = ts.request("GET", "/api/sessions", loggedIn.Token, nil)
+	expectStatus(t, rec, 200)
+
+	// Recovery codes work once.
+	rec = ts.request("POST", "/api/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "recovery_code": recoveryCodes[0]})
+	expectStatus(t, rec, 401)
+}
+
+func TestLoginMFA_RejectsAccessToken(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	_, recoveryCodes := ts.enrollTOTP(user)
+
+	rec := ts.request("POST", "/api/login/mfa", "", map[string]string{"mfa_token": user.Token, "recovery_code": recoveryCodes[0]})
+	expectStatus(t, rec, 401)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	secret, recoveryCodes := ts.enrollTOTP(user)
+
+	rec := ts.request("DELETE", "/api/mfa/totp", user.Token, map[string]string{"code": wrongTOTPCode(t, secret)})
+	expectStatus(t, rec, 401)
+	rec = ts.request("DELETE", "/api/mfa/totp", user.Token, map[string]string{"recovery_code": recoveryCodes[0]})
+	expectStatus(t, rec, 204)
+
+	ts.login("walt@breakingbad.com")
+}
+
+func TestDisableTOTP_Throttled(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	secret, recoveryCodes := ts.enrollTOTP(user)
+
+	// A stolen session must not be enough to guess the code and turn two-factor
+	// authentication off.
+	for range testAccountPolicy.MaxFailures {
+		rec := ts.request("DELETE", "/api/mfa/totp", user.Token, map[string]string{"code": wrongTOTPCode(t, secret)})
+		expectStatus(t, rec, 401)
+	}
+	rec := ts.request("DELETE", "/api/mfa/totp", user.Token, map[string]string{"recovery_code": recoveryCodes[0]})
+	expectStatus(t, rec, 429)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// OAuthClient is a third-party app registered to sign users in with Chirpy.
+// The client secret is only ever returned when the client is registered.
+type OAuthClient struct {
+	ID           uuid.UUID `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Public       bool      `json:"public"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuthorizedApp is a client a user has granted access to their account.
+type AuthorizedApp struct {
+	ClientID  uuid.UUID `json:"client_id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (cfg *apiConfig) handleCreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Public       bool     `json:"public"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	if params.Name == "" {
+		respondWithError(w, 400, "name is required")
+		return
+	}
+	if len(params.RedirectURIs) == 0 {
+		respondWithError(w, 400, "at least one redirect uri is required")
+		return
+	}
+	for _, redirectURI := range params.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			respondWithError(w, 400, "invalid redirect uri "+redirectURI)
+			return
+		}
+	}
+	if !auth.ValidScopes(params.Scopes) {
+		respondWithError(w, 400, "invalid scopes")
+		return
+	}
+
+	var secret string
+	var secretHash sql.NullString
+	if !params.Public {
+		secret, err = auth.MakeOpaqueToken()
+		if err != nil {
+			respondWithError(w, 500, "cannot generate client secret")
+			return
+		}
+		secretHash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+
+	client, err := cfg.db.CreateOAuthClient(r.Context(), database.CreateOAuthClientParams{
+		OwnerID:      userID,
+		Name:         params.Name,
+		SecretHash:   secretHash,
+		RedirectUris: params.RedirectURIs,
+		Scopes:       params.Scopes,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot create client")
+		return
+	}
+
+	response := dbOAuthClientToOAuthClientStruct(client)
+	response.ClientSecret = secret
+	respondWithJson(w, 201, response)
+}
+
+func (cfg *apiConfig) handleListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	dbClients, err := cfg.db.ListOAuthClientsForOwner(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch clients")
+		return
+	}
+
+	clients := []OAuthClient{}
+	for _, client := range dbClients {
+		clients = append(clients, dbOAuthClientToOAuthClientStruct(client))
+	}
+
+	respondWithJson(w, 200, clients)
+}
+
+func (cfg *apiConfig) handleDeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid client id")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteOAuthClient(r.Context(), database.DeleteOAuthClientParams{
+		ID:      clientID,
+		OwnerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot delete client")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	respondWithJson(w, 204, nil)
+}
+
+func (cfg *apiConfig) handleListAuthorizedApps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	grants, err := cfg.db.ListOAuthGrantsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch apps")
+		return
+	}
+
+	apps := []AuthorizedApp{}
+	for _, grant := range grants {
+		apps = append(apps, AuthorizedApp{
+			ClientID:  grant.ClientID,
+			Name:      grant.ClientName,
+			Scopes:    grant.Scopes,
+			CreatedAt: grant.CreatedAt,
+			UpdatedAt: grant.UpdatedAt,
+		})
+	}
+
+	respondWithJson(w, 200, apps)
+}
+
+// handleRevokeAuthorizedApp withdraws a client's access. Its access tokens
+// stop working straight away since they are checked against the grant.
+func (cfg *apiConfig) handleRevokeAuthorizedApp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid client id")
+		return
+	}
+
+	revoked, err := cfg.db.RevokeOAuthGrant(r.Context(), database.RevokeOAuthGrantParams{
+		UserID:   userID,
+		ClientID: clientID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot revoke app")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditOAuthAppRevoked,
+		UserID:   userID,
+		Metadata: map[string]any{"client_id": clientID},
+	})
+	respondWithJson(w, 204, nil)
+}
+
+// validRedirectURI accepts absolute https URIs, and http ones pointing at the
+// loopback interface for native apps. Fragments are not allowed (RFC 6749
+// section 3.1.2).
+func validRedirectURI(redirectURI string) bool {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
+
+func dbOAuthClientToOAuthClientStruct(client database.OauthClient) OAuthClient {
+	return OAuthClient{
+		ID:           client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		Public:       !client.SecretHash.Valid,
+		CreatedAt:    client.CreatedAt,
+	}
+}
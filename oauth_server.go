@@ -0,0 +1,562 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/oidc"
+)
+
+const oauthAuthorizationCodeDuration = time.Minute
+const oauthAccessTokenDuration = time.Hour
+const oauthRefreshTokenDuration = time.Duration(30*24) * time.Hour
+
+var scopeDescriptions = map[string]string{
+	auth.ScopeChirpsWrite:  "Post and delete chirps as you",
+	auth.ScopeAccountRead:  "See your account details and sessions",
+	auth.ScopeAccountWrite: "Change your account details and sign out your sessions",
+}
+
+var consentTemplate = template.Must(template.New("consent").Parse(`<html>
+  <head><title>Authorize {{.ClientName}}</title></head>
+  <body>
+    <h1>{{.ClientName}} wants to access your Chirpy account</h1>
+    <p>It will be able to:</p>
+    <ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+    {{if .Error}}<p><strong>{{.Error}}</strong></p>{{end}}
+    <form method="post" action="/oauth/authorize">
+      {{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+      {{end}}
+      <p><label>Email <input type="email" name="email" value="{{.Email}}" required></label></p>
+      <p><label>Password <input type="password" name="password"></label></p>
+      <p><label>Two-factor code, if enabled <input type="text" name="code" autocomplete="one-time-code"></label></p>
+      <button type="submit" name="decision" value="allow">Allow</button>
+      <button type="submit" name="decision" value="deny" formnovalidate>Deny</button>
+    </form>
+  </body>
+</html>
+`))
+
+// authorizeRequest is a validated authorization request (RFC 6749 section
+// 4.1.1) with PKCE (RFC 7636).
+type authorizeRequest struct {
+	client        database.OauthClient
+	redirectURI   string
+	state         string
+	scopes        []string
+	codeChallenge string
+}
+
+// parseAuthorizeRequest validates an authorization request. Until the client
+// and redirect URI are known to be good errors cannot be sent back to the
+// client, so a nil request is returned with a message for the user. Later
+// errors come with the request and an OAuth error code to redirect with.
+func (cfg *apiConfig) parseAuthorizeRequest(ctx context.Context, values url.Values) (*authorizeRequest, string, error) {
+	clientID, err := uuid.Parse(values.Get("client_id"))
+	if err != nil {
+		return nil, "", errors.New("invalid client_id")
+	}
+	client, err := cfg.db.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return nil, "", errors.New("unknown client")
+	}
+
+	redirectURI := values.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectUris) == 1 {
+		redirectURI = client.RedirectUris[0]
+	}
+	if !slices.Contains(client.RedirectUris, redirectURI) {
+		return nil, "", errors.New("redirect_uri is not registered for this client")
+	}
+
+	req := &authorizeRequest{
+		client:        client,
+		redirectURI:   redirectURI,
+		state:         values.Get("state"),
+		scopes:        strings.Fields(values.Get("scope")),
+		codeChallenge: values.Get("code_challenge"),
+	}
+
+	if values.Get("response_type") != "code" {
+		return req, "unsupported_response_type", errors.New("only the code response type is supported")
+	}
+	if len(req.scopes) == 0 {
+		req.scopes = client.Scopes
+	}
+	for _, scope := range req.scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return req, "invalid_scope", errors.New("scope " + scope + " is not allowed for this client")
+		}
+	}
+	if req.codeChallenge == "" || values.Get("code_challenge_method") != "S256" {
+		return req, "invalid_request", errors.New("PKCE with the S256 method is required")
+	}
+
+	return req, "", nil
+}
+
+// redirect sends the user back to the client with params added to the
+// redirect URI's query.
+func (req *authorizeRequest) redirect(w http.ResponseWriter, r *http.Request, params url.Values) {
+	if req.state != "" {
+		params.Set("state", req.state)
+	}
+	separator := "?"
+	if strings.Contains(req.redirectURI, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, req.redirectURI+separator+params.Encode(), http.StatusFound)
+}
+
+func (req *authorizeRequest) redirectWithError(w http.ResponseWriter, r *http.Request, code string, err error) {
+	req.redirect(w, r, url.Values{"error": {code}, "error_description": {err.Error()}})
+}
+
+func (cfg *apiConfig) handleAuthorizePage(w http.ResponseWriter, r *http.Request) {
+	req, errCode, err := cfg.parseAuthorizeRequest(r.Context(), r.URL.Query())
+	if req == nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if err != nil {
+		req.redirectWithError(w, r, errCode, err)
+		return
+	}
+
+	renderConsentPage(w, 200, req, r.URL.Query(), "", "")
+}
+
+func (cfg *apiConfig) handleAuthorizeDecision(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithError(w, 400, "cannot parse form")
+		return
+	}
+
+	req, errCode, err := cfg.parseAuthorizeRequest(r.Context(), r.PostForm)
+	if req == nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if err != nil {
+		req.redirectWithError(w, r, errCode, err)
+		return
+	}
+
+	if r.PostForm.Get("decision") != "allow" {
+		req.redirectWithError(w, r, "access_denied", errors.New("the user denied access"))
+		return
+	}
+
+	email := r.PostForm.Get("email")
+	user, status, err := cfg.authenticateConsent(r, email, r.PostForm.Get("password"), r.PostForm.Get("code"))
+	if err != nil {
+		renderConsentPage(w, status, req, r.PostForm, email, err.Error())
+		return
+	}
+
+	grant, err := cfg.db.UpsertOAuthGrant(r.Context(), database.UpsertOAuthGrantParams{
+		UserID:   user.ID,
+		ClientID: req.client.ID,
+		Scopes:   req.scopes,
+	})
+	if err != nil {
+		req.redirectWithError(w, r, "server_error", errors.New("cannot save authorization"))
+		return
+	}
+
+	code, err := auth.MakeOpaqueToken()
+	if err != nil {
+		req.redirectWithError(w, r, "server_error", errors.New("cannot generate code"))
+		return
+	}
+	err = cfg.db.CreateOAuthAuthorizationCode(r.Context(), database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		GrantID:       grant.ID,
+		RedirectUri:   req.redirectURI,
+		CodeChallenge: req.codeChallenge,
+		Scopes:        req.scopes,
+		ExpiresAt:     time.Now().Add(oauthAuthorizationCodeDuration),
+	})
+	if err != nil {
+		req.redirectWithError(w, r, "server_error", errors.New("cannot save code"))
+		return
+	}
+
+	req.redirect(w, r, url.Values{"code": {code}})
+}
+
+// authenticateConsent checks the credentials entered on the consent page the
+// same way POST /api/login and POST /api/login/mfa do, throttling included.
+// Like any login, it restores an account scheduled for deletion.
+func (cfg *apiConfig) authenticateConsent(r *http.Request, email, password, code string) (database.User, int, error) {
+	accountKey := "account:" + strings.ToLower(email)
+	ipKey := "ip:" + clientIP(r)
+	wait, err := cfg.startLoginAttempt(r.Context(), accountKey, ipKey)
+	if err != nil {
+		return database.User{}, 500, errors.New("cannot check login attempts")
+	}
+	if wait > 0 {
+		return database.User{}, 429, errors.New("too many failed login attempts, try again later")
+	}
+
+	invalid := errors.New("incorrect email, password or code")
+
+	user, err := cfg.db.GetUserByEmail(r.Context(), email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, 401, invalid
+	}
+	if err != nil {
+		return database.User{}, 500, errors.New("error fetching user")
+	}
+	matchesPwd, _, err := cfg.passwords.Verify(password, user.HashedPassword)
+	if err != nil && !errors.Is(err, auth.ErrUnknownHashFormat) {
+		return database.User{}, 500, errors.New("cannot check password")
+	}
+	if !matchesPwd {
+		return database.User{}, 401, invalid
+	}
+
+	totp, err := cfg.db.GetTOTP(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, 500, errors.New("cannot check two-factor authentication")
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		verified, err := cfg.verifySecondFactor(r.Context(), user.ID, code, "")
+		if err != nil {
+			return database.User{}, 500, errors.New("cannot verify code")
+		}
+		if !verified {
+			return database.User{}, 401, invalid
+		}
+	}
+
+	cfg.finishLoginAttempt(r, accountKey, ipKey)
+	cfg.cancelAccountDeletion(r, user)
+	return user, 200, nil
+}
+
+func renderConsentPage(w http.ResponseWriter, code int, req *authorizeRequest, values url.Values, email, errMsg string) {
+	params := map[string]string{}
+	for _, name := range []string{"response_type", "client_id", "redirect_uri", "scope", "state", "code_challenge", "code_challenge_method"} {
+		if value := values.Get(name); value != "" {
+			params[name] = value
+		}
+	}
+	var scopes []string
+	for _, scope := range req.scopes {
+		scopes = append(scopes, scopeDescriptions[scope])
+	}
+
+	// The page takes credentials, so it must never be framed by another site.
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	err := consentTemplate.Execute(w, map[string]any{
+		"ClientName": req.client.Name,
+		"Scopes":     scopes,
+		"Params":     params,
+		"Email":      email,
+		"Error":      errMsg,
+	})
+	if err != nil {
+		log.Printf("Error rendering consent page: %s", err)
+	}
+}
+
+// authenticateOAuthClient identifies the client calling a token endpoint by
+// HTTP basic auth or client_id and client_secret form fields. Public clients
+// only send their client_id.
+func (cfg *apiConfig) authenticateOAuthClient(r *http.Request) (database.OauthClient, bool) {
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	clientID, err := uuid.Parse(id)
+	if err != nil {
+		return database.OauthClient{}, false
+	}
+	client, err := cfg.db.GetOAuthClient(r.Context(), clientID)
+	if err != nil {
+		return database.OauthClient{}, false
+	}
+	if client.SecretHash.Valid && subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(client.SecretHash.String)) != 1 {
+		return database.OauthClient{}, false
+	}
+	return client, true
+}
+
+// respondWithOAuthError sends an error response as described in RFC 6749
+// section 5.2.
+func respondWithOAuthError(w http.ResponseWriter, code int, errCode, description string) {
+	type oauthError struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJson(w, code, oauthError{Error: errCode, ErrorDescription: description})
+}
+
+func (cfg *apiConfig) handleOAuthToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "cannot parse form")
+		return
+	}
+
+	client, ok := cfg.authenticateOAuthClient(r)
+	if !ok {
+		respondWithOAuthError(w, 401, "invalid_client", "")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	var grantID uuid.UUID
+	var scopes []string
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code, err := qtx.ConsumeOAuthAuthorizationCode(r.Context(), auth.HashToken(r.PostForm.Get("code")))
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithOAuthError(w, 400, "invalid_grant", "invalid or expired code")
+			return
+		}
+		if err != nil {
+			respondWithOAuthError(w, 500, "server_error", "")
+			return
+		}
+		if code.RedirectUri != r.PostForm.Get("redirect_uri") {
+			respondWithOAuthError(w, 400, "invalid_grant", "redirect_uri does not match")
+			return
+		}
+		verifier := r.PostForm.Get("code_verifier")
+		if len(verifier) < 43 || len(verifier) > 128 || oidc.CodeChallenge(verifier) != code.CodeChallenge {
+			respondWithOAuthError(w, 400, "invalid_grant", "code_verifier does not match")
+			return
+		}
+		grantID, scopes = code.GrantID, code.Scopes
+	case "refresh_token":
+		refreshToken, err := qtx.ConsumeOAuthRefreshToken(r.Context(), auth.HashToken(r.PostForm.Get("refresh_token")))
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithOAuthError(w, 400, "invalid_grant", "invalid or expired refresh token")
+			return
+		}
+		if err != nil {
+			respondWithOAuthError(w, 500, "server_error", "")
+			return
+		}
+		grantID, scopes = refreshToken.GrantID, refreshToken.Scopes
+		if requested := strings.Fields(r.PostForm.Get("scope")); len(requested) > 0 {
+			for _, scope := range requested {
+				if !slices.Contains(scopes, scope) {
+					respondWithOAuthError(w, 400, "invalid_scope", "scope "+scope+" was not granted")
+					return
+				}
+			}
+			scopes = requested
+		}
+	default:
+		respondWithOAuthError(w, 400, "unsupported_grant_type", "")
+		return
+	}
+
+	grant, err := qtx.GetOAuthGrant(r.Context(), grantID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && grant.ClientID != client.ID) {
+		respondWithOAuthError(w, 400, "invalid_grant", "the authorization was revoked or belongs to another client")
+		return
+	}
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+
+	accessToken, err := cfg.keys.MakeOAuthAccessToken(grant.UserID, client.ID, grant.ID, scopes, oauthAccessTokenDuration)
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "cannot generate access token")
+		return
+	}
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "cannot generate refresh token")
+		return
+	}
+	err = qtx.CreateOAuthRefreshToken(r.Context(), database.CreateOAuthRefreshTokenParams{
+		TokenHash: auth.HashToken(refreshToken),
+		GrantID:   grant.ID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(oauthRefreshTokenDuration),
+	})
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "cannot create refresh token")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+
+	type tokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJson(w, 200, tokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(oauthAccessTokenDuration.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(scopes, " "),
+	})
+}
+
+// handleOAuthRevoke implements RFC 7009. Refresh tokens are revoked; access
+// tokens are short-lived JWTs that cannot be revoked one by one, so they are
+// reported as unsupported. Unknown tokens succeed as the RFC requires.
+func (cfg *apiConfig) handleOAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "cannot parse form")
+		return
+	}
+
+	client, ok := cfg.authenticateOAuthClient(r)
+	if !ok {
+		respondWithOAuthError(w, 401, "invalid_client", "")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	refreshToken, err := cfg.db.GetOAuthRefreshToken(r.Context(), auth.HashToken(token))
+	if err == nil {
+		grant, err := cfg.db.GetOAuthGrant(r.Context(), refreshToken.GrantID)
+		if err == nil && grant.ClientID == client.ID {
+			err = cfg.db.RevokeOAuthRefreshToken(r.Context(), refreshToken.TokenHash)
+			if err != nil {
+				respondWithOAuthError(w, 503, "temporarily_unavailable", "")
+				return
+			}
+		}
+		w.WriteHeader(200)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithOAuthError(w, 503, "temporarily_unavailable", "")
+		return
+	}
+
+	claims, err := cfg.keys.ValidateOAuthAccessToken(token)
+	if err == nil && claims.ClientID == client.ID.String() {
+		respondWithOAuthError(w, 400, "unsupported_token_type", "access tokens expire on their own; revoke the refresh token")
+		return
+	}
+
+	w.WriteHeader(200)
+}
+
+// handleOAuthIntrospect implements RFC 7662 for confidential clients. A
+// client only learns about its own tokens; anything else is inactive.
+func (cfg *apiConfig) handleOAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "cannot parse form")
+		return
+	}
+
+	client, ok := cfg.authenticateOAuthClient(r)
+	if !ok || !client.SecretHash.Valid {
+		respondWithOAuthError(w, 401, "invalid_client", "")
+		return
+	}
+
+	type introspectionResponse struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Sub       string `json:"sub,omitempty"`
+		Exp       int64  `json:"exp,omitempty"`
+		Iat       int64  `json:"iat,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	token := r.PostForm.Get("token")
+	if claims, err := cfg.keys.ValidateOAuthAccessToken(token); err == nil {
+		if claims.ClientID != client.ID.String() || !cfg.oauthGrantActive(r.Context(), claims.GrantID) {
+			respondWithJson(w, 200, introspectionResponse{})
+			return
+		}
+		respondWithJson(w, 200, introspectionResponse{
+			Active:    true,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Sub:       claims.Subject,
+			Exp:       claims.ExpiresAt.Unix(),
+			Iat:       claims.IssuedAt.Unix(),
+			TokenType: "access_token",
+		})
+		return
+	}
+
+	refreshToken, err := cfg.db.GetOAuthRefreshToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		respondWithJson(w, 200, introspectionResponse{})
+		return
+	}
+	grant, err := cfg.db.GetOAuthGrant(r.Context(), refreshToken.GrantID)
+	if err != nil || grant.ClientID != client.ID {
+		respondWithJson(w, 200, introspectionResponse{})
+		return
+	}
+	respondWithJson(w, 200, introspectionResponse{
+		Active:    true,
+		Scope:     strings.Join(refreshToken.Scopes, " "),
+		ClientID:  client.ID.String(),
+		Sub:       grant.UserID.String(),
+		Exp:       refreshToken.ExpiresAt.Unix(),
+		Iat:       refreshToken.CreatedAt.Unix(),
+		TokenType: "refresh_token",
+	})
+}
+
+// oauthGrantActive reports whether the user has not revoked the grant an
+// OAuth access token was issued under.
+func (cfg *apiConfig) oauthGrantActive(ctx context.Context, grantID string) bool {
+	id, err := uuid.Parse(grantID)
+	if err != nil {
+		return false
+	}
+	_, err = cfg.db.GetOAuthGrant(ctx, id)
+	return err == nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/oidc"
+)
+
+const testRedirectURI = "https://app.example/callback"
+
+type testOAuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// authorize registers a public client for owner and has user approve it,
+// returning the client and the authorization code it was sent back.
+func (ts *testServer) authorize(owner User, email, verifier string, scopes ...string) (OAuthClient, string) {
+	ts.t.Helper()
+	rec := ts.request("POST", "/api/oauth/clients", owner.Token, map[string]any{
+		"name":          "Heisenberg Labs",
+		"redirect_uris": []string{testRedirectURI},
+		"scopes":        scopes,
+		"public":        true,
+	})
+	expectStatus(ts.t, rec, 201)
+	client := decodeResponse[OAuthClient](ts.t, rec)
+
+	rec = ts.postForm("/oauth/authorize", url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ID.String()},
+		"redirect_uri":          {testRedirectURI},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {"xyzzy"},
+		"code_challenge":        {oidc.CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+		"decision":              {"allow"},
+		"email":                 {email},
+		"password":              {testPassword},
+	})
+	expectStatus(ts.t, rec, 302)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), testRedirectURI+"?") {
+		ts.t.Fatalf("expected a redirect to the client, got %q", rec.Header().Get("Location"))
+	}
+	if location.Query().Get("state") != "xyzzy" {
+		ts.t.Errorf("expected state to be passed back, got %q", location.Query().Get("state"))
+	}
+	code := location.Query().Get("code")
+	if code == "" {
+		ts.t.Fatalf("expected a code, got %q", location)
+	}
+	return client, code
+}
+
+func TestOAuth_AuthorizationCodeWithPKCE(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	verifier := strings.Repeat("blue-sky-", 6)
+	client, code := ts.authorize(user, "walt@breakingbad.com", verifier, auth.ScopeAccountRead)
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"client_id":     {client.ID.String()},
+		"code_verifier": {strings.Repeat("crystal-", 6)},
+	}
+	rec := ts.postForm("/oauth/token", exchange)
+	expectStatus(t, rec, 400)
+
+	exchange.Set("code_verifier", verifier)
+	exchange.Set("redirect_uri", "https://app.example/other")
+	rec = ts.postForm("/oauth/token", exchange)
+	expectStatus(t, rec, 400)
+
+	exchange.Set("redirect_uri", testRedirectURI)
+	rec = ts.postForm("/oauth/token", exchange)
+	expectStatus(t, rec, 200)
+	tokens := decodeResponse[testOAuthTokens](t, rec)
+	if tokens.Scope != auth.ScopeAccountRead {
+		t.Errorf("expected scope %q, got %q", auth.ScopeAccountRead, tokens.Scope)
+	}
+
+	// Codes work once.
+	rec = ts.postForm("/oauth/token", exchange)
+	expectStatus(t, rec, 400)
+
+	rec = ts.request("GET", "/api/sessions", tokens.AccessToken, nil)
+	expectStatus(t, rec, 200)
+	rec = ts.request("DELETE", "/api/sessions", tokens.AccessToken, nil)
+	expectStatus(t, rec, 403)
+}
+
+func TestOAuth_RefreshToken(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	verifier := strings.Repeat("blue-sky-", 6)
+	client, code := ts.authorize(user, "walt@breakingbad.com", verifier, auth.ScopeAccountRead, auth.ScopeChirpsWrite)
+
+	rec := ts.postForm("/oauth/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"client_id":     {client.ID.String()},
+		"code_verifier": {verifier},
+	})
+	expectStatus(t, rec, 200)
+	tokens := decodeResponse[testOAuthTokens](t, rec)
+
+	refresh := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens.RefreshToken},
+		"client_id":     {client.ID.String()},
+		"scope":         {auth.ScopeChirpsWrite},
+	}
+	rec = ts.postForm("/oauth/token", refresh)
+	expectStatus(t, rec, 200)
+	refreshed := decodeResponse[testOAuthTokens](t, rec)
+	if refreshed.Scope != auth.ScopeChirpsWrite {
+		t.Errorf("expected scope narrowed to %q, got %q", auth.ScopeChirpsWrite, refreshed.Scope)
+	}
+	rec = ts.request("GET", "/api/sessions", refreshed.AccessToken, nil)
+	expectStatus(t, rec, 403)
+
+	// Refresh tokens rotate.
+	rec = ts.postForm("/oauth/token", refresh)
+	expectStatus(t, rec, 400)
+
+	// Scopes cannot be widened past the original grant.
+	rec = ts.postForm("/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshed.RefreshToken},
+		"client_id":     {client.ID.String()},
+		"scope":         {auth.ScopeAccountWrite},
+	})
+	expectStatus(t, rec, 400)
+}
+
+func TestOAuth_ConsentRestoresDeletedAccount(t *testing.T) {
+	ts := newTestServer(t)
+	developer := ts.createUser("gale@breakingbad.com")
+	user := ts.createUser("walt@breakingbad.com")
+	rec := ts.request("DELETE", "/api/users", user.Token, map[string]string{"password": testPassword})
+	expectStatus(t, rec, 202)
+
+	// Approving an app with the password is a login, so the account must not
+	// be purged afterwards.
+	verifier := strings.Repeat("blue-sky-", 6)
+	client, code := ts.authorize(developer, "walt@breakingbad.com", verifier, auth.ScopeAccountRead)
+	restored, err := ts.cfg.db.GetUser(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("cannot fetch user: %v", err)
+	}
+	if restored.DeletionRequestedAt.Valid {
+		t.Errorf("expected the deletion to be cancelled")
+	}
+
+	rec = ts.postForm("/oauth/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"client_id":     {client.ID.String()},
+		"code_verifier": {verifier},
+	})
+	expectStatus(t, rec, 200)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/oidc"
+)
+
+const oidcLoginStateDuration = 10 * time.Minute
+const oidcStateCookie = "chirpy_oidc_state"
+
+// unsetPassword is the hashed_password of accounts created through an
+// external provider. No hasher recognizes it, so password login fails.
+const unsetPassword = "unset"
+
+// loadOIDCProviders configures the providers named in OIDC_PROVIDERS, a comma
+// separated list. Each name reads OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and optionally OIDC_<NAME>_SCOPES.
+func loadOIDCProviders(baseURL string) map[string]*oidc.Provider {
+	providers := map[string]*oidc.Provider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  baseURL + "/api/oauth/" + name + "/callback",
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			log.Fatalf("%sISSUER and %sCLIENT_ID must be set", prefix, prefix)
+		}
+		providers[name] = oidc.NewProvider(config, &http.Client{Timeout: 10 * time.Second})
+	}
+	return providers
+}
+
+func (cfg *apiConfig) handleStartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	providerName := r.PathValue("provider")
+	provider, ok := cfg.oidcProviders[providerName]
+	if !ok {
+		respondWithError(w, 404, "unknown provider")
+		return
+	}
+
+	state, err := auth.MakeOpaqueToken()
+	if err != nil {
+		respondWithError(w, 500, "cannot start login")
+		return
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		respondWithError(w, 500, "cannot start login")
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		respondWithError(w, 500, "cannot start login")
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		log.Printf("Error starting %s login: %s", providerName, err)
+		respondWithError(w, 502, "identity provider unavailable")
+		return
+	}
+
+	err = cfg.db.CreateOIDCLoginState(r.Context(), database.CreateOIDCLoginStateParams{
+		StateHash:    auth.HashToken(state),
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcLoginStateDuration),
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot start login")
+		return
+	}
+
+	// The state is also kept in a cookie so the callback only completes in the
+	// browser that started the login.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/oauth/",
+		MaxAge:   int(oidcLoginStateDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(cfg.baseURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (cfg *apiConfig) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	providerName := r.PathValue("provider")
+	provider, ok := cfg.oidcProviders[providerName]
+	if !ok {
+		respondWithError(w, 404, "unknown provider")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api/oauth/", MaxAge: -1})
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		respondWithError(w, 401, "login failed: "+providerError)
+		return
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		respondWithError(w, 400, "invalid state")
+		return
+	}
+
+	loginState, err := cfg.db.ConsumeOIDCLoginState(r.Context(), database.ConsumeOIDCLoginStateParams{
+		StateHash: auth.HashToken(state),
+		Provider:  providerName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "invalid or expired state")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot complete login")
+		return
+	}
+
+	token, err := provider.Exchange(r.Context(), query.Get("code"), loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("Error exchanging %s authorization code: %s", providerName, err)
+		respondWithError(w, 401, "unauthorized")
+		return
+	}
+	claims, err := provider.VerifyIDToken(r.Context(), token.IDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("Error verifying %s ID token: %s", providerName, err)
+		respondWithError(w, 401, "unauthorized")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot complete login")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err := qtx.GetUserByIdentity(r.Context(), database.GetUserByIdentityParams{
+		Provider: providerName,
+		Subject:  claims.Subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		if claims.Email == "" || !claims.EmailVerified {
+			respondWithError(w, 403, "the identity provider did not confirm an email address")
+			return
+		}
+
+		user, err = qtx.GetUserByEmail(r.Context(), claims.Email)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			user, err = qtx.CreateUser(r.Context(), database.CreateUserParams{
+				Email:          claims.Email,
+				HashedPassword: unsetPassword,
+			})
+			if err != nil {
+				respondWithError(w, 500, "cannot create user")
+				return
+			}
+			user, err = qtx.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+				ID:    user.ID,
+				Email: user.Email,
+			})
+		case err == nil && !user.EmailVerifiedAt.Valid:
+			// Whoever registered the address here never proved they own it, so
+			// linking would hand the provider's user an account set up by
+			// someone else.
+			respondWithError(w, 409, "an unverified account already uses this email address")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 500, "cannot complete login")
+			return
+		}
+
+		err = qtx.CreateUserIdentity(r.Context(), database.CreateUserIdentityParams{
+			UserID:   user.ID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		})
+		if err != nil {
+			respondWithError(w, 500, "cannot link identity")
+			return
+		}
+	} else if err != nil {
+		respondWithError(w, 500, "error fetching user")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot complete login")
+		return
+	}
+
+	cfg.completeLogin(w, r, user, "oidc:"+providerName)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/mailer"
+)
+
+const passwordResetTokenDuration = 30 * time.Minute
+
+func (cfg *apiConfig) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	// Unknown addresses get the same response so accounts cannot be enumerated.
+	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJson(w, 202, nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "error fetching user")
+		return
+	}
+
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		respondWithError(w, 500, "cannot generate reset token")
+		return
+	}
+
+	err = cfg.db.CreatePasswordResetToken(r.Context(), database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(passwordResetTokenDuration),
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot create reset token")
+		return
+	}
+
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password of your Chirpy account.\n\n"+
+				"Send this token along with your new password to POST /api/password/reset\n"+
+				"within %d minutes:\n\n%s\n\n"+
+				"If it wasn't you, you can ignore this email.",
+			int(passwordResetTokenDuration.Minutes()), token,
+		),
+	})
+
+	respondWithJson(w, 202, nil)
+}
+
+func (cfg *apiConfig) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	resetToken, err := qtx.ConsumePasswordResetToken(r.Context(), auth.HashToken(params.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "invalid or expired token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+
+	// The token is only spent once the new password is accepted, since the
+	// transaction rolls back on every early return.
+	user, err := qtx.GetUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+	if !cfg.checkPasswordPolicy(w, params.Password, user.Email) {
+		return
+	}
+
+	hashedPassword, err := cfg.passwords.Hash(params.Password)
+	if err != nil {
+		respondWithError(w, 500, "hashing error")
+		return
+	}
+
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             resetToken.UserID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+	err = qtx.InvalidatePasswordResetTokensForUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+	err = qtx.RevokeAllRefreshTokensForUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "cannot reset password")
+		return
+	}
+
+	cfg.audit(r, auditEvent{Type: auditPasswordReset, UserID: resetToken.UserID})
+
+	respondWithJson(w, 204, nil)
+}
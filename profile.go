@@ -0,0 +1,305 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+	"github.com/sidis405/chirpy/internal/mailer"
+)
+
+// recentLoginWindow is how recently users without a password must have
+// logged in to confirm sensitive changes to their account.
+const recentLoginWindow = 10 * time.Minute
+
+// handleUpdateProfile applies a partial update to the caller's profile. Fields
+// left out of the body are not touched. Changing the email takes the current
+// password, as the new address is what password resets go to.
+func (cfg *apiConfig) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Email           *string `json:"email"`
+		Password        *string `json:"password"`
+		CurrentPassword string  `json:"current_password"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	if params.Password != nil {
+		respondWithError(w, 400, "use POST /api/users/me/password to change the password")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	pendingEmail := ""
+	if params.Email != nil && *params.Email != user.Email {
+		if !cfg.confirmIdentity(w, r, user, params.CurrentPassword, "change_email") {
+			return
+		}
+		pendingEmail, ok = cfg.checkEmailChange(w, r, user, *params.Email)
+		if !ok {
+			return
+		}
+	}
+	if pendingEmail != "" {
+		err = cfg.startEmailChange(r, user, pendingEmail)
+		if err != nil {
+			respondWithError(w, 500, "cannot send verification email")
+			return
+		}
+	}
+
+	userResponse := dbUserToUserStruct(user)
+	userResponse.PendingEmail = pendingEmail
+	respondWithJson(w, 200, userResponse)
+}
+
+// handleChangePassword replaces the caller's password once they confirm the
+// current one. Every other session is signed out.
+func (cfg *apiConfig) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	if !cfg.confirmPassword(w, r, user, params.CurrentPassword, "change_password") {
+		return
+	}
+
+	if !cfg.checkPasswordPolicy(w, params.NewPassword, user.Email) {
+		return
+	}
+
+	err = cfg.changePassword(r, userID, params.NewPassword)
+	if err != nil {
+		respondWithError(w, 500, "cannot change password")
+		return
+	}
+
+	respondWithJson(w, 204, nil)
+}
+
+// confirmIdentity makes sure a sensitive change to user's account comes from
+// its owner: by their current password, or for accounts created through an
+// identity provider, which have none, by having just logged in.
+func (cfg *apiConfig) confirmIdentity(w http.ResponseWriter, r *http.Request, user database.User, password, action string) bool {
+	if user.HashedPassword == unsetPassword {
+		return cfg.checkRecentLogin(w, r, recentLoginWindow)
+	}
+	return cfg.confirmPassword(w, r, user, password, action)
+}
+
+// confirmPassword checks the current password sent along with a sensitive
+// change, throttled like logins.
+func (cfg *apiConfig) confirmPassword(w http.ResponseWriter, r *http.Request, user database.User, password, action string) bool {
+	accountKey := "account:" + strings.ToLower(user.Email)
+	ipKey := "ip:" + clientIP(r)
+	if !cfg.throttleLoginAttempt(w, r, accountKey, ipKey) {
+		return false
+	}
+	matchesPwd, _, err := cfg.passwords.Verify(password, user.HashedPassword)
+	if err != nil && !errors.Is(err, auth.ErrUnknownHashFormat) {
+		respondWithError(w, 500, "cannot check pwd")
+		return false
+	}
+	if !matchesPwd {
+		cfg.audit(r, auditEvent{
+			Type:     auditLoginFailed,
+			UserID:   user.ID,
+			Metadata: map[string]any{"method": "password", "reason": "wrong_password", "action": action},
+		})
+		respondWithError(w, 401, "incorrect password")
+		return false
+	}
+	cfg.finishLoginAttempt(r, accountKey, ipKey)
+	return true
+}
+
+// checkRecentLogin makes sure the request's session was started by a login
+// within maxAge, rather than kept alive with refresh tokens since.
+func (cfg *apiConfig) checkRecentLogin(w http.ResponseWriter, r *http.Request, maxAge time.Duration) bool {
+	claims := cfg.sessionClaims(r)
+	if claims == nil {
+		respondWithError(w, 401, "invalid token")
+		return false
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		respondWithError(w, 401, "invalid token")
+		return false
+	}
+
+	recent, err := cfg.db.IsRecentSession(r.Context(), database.IsRecentSessionParams{
+		FamilyID:      sessionID,
+		UserID:        claims.UserID,
+		MaxAgeSeconds: maxAge.Seconds(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot check session")
+		return false
+	}
+	if !recent {
+		respondWithError(w, 403, "log in again to confirm")
+		return false
+	}
+	return true
+}
+
+// checkEmailChange validates a request to move currentUser to email. It
+// returns the address that now needs verifying, or "" when email is already
+// the current one.
+func (cfg *apiConfig) checkEmailChange(w http.ResponseWriter, r *http.Request, currentUser database.User, email string) (string, bool) {
+	if email == currentUser.Email {
+		return "", true
+	}
+	if email == "" {
+		respondWithError(w, 400, "email is required")
+		return "", false
+	}
+
+	_, err := cfg.db.GetUserByEmail(r.Context(), email)
+	if err == nil {
+		respondWithError(w, 409, "email already in use")
+		return "", false
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "error fetching user")
+		return "", false
+	}
+	return email, true
+}
+
+// startEmailChange mails a verification link to newEmail. The address only
+// replaces user's current one once the link is followed. The current address
+// is told about the change, in case it was not its owner who asked.
+func (cfg *apiConfig) startEmailChange(r *http.Request, user database.User, newEmail string) error {
+	err := cfg.sendVerificationEmail(r.Context(), user.ID, newEmail)
+	if err != nil {
+		return err
+	}
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy email address is being changed",
+		Body: fmt.Sprintf(
+			"Someone asked to change the email address of your Chirpy account to %s. "+
+				"The change takes effect once that address is verified.\n\n"+
+				"If it was not you, change your password and sign out your sessions now.",
+			newEmail,
+		),
+	})
+
+	cfg.audit(r, auditEvent{
+		Type:     auditEmailChangeRequested,
+		UserID:   user.ID,
+		Metadata: map[string]any{"old_email": user.Email, "new_email": newEmail},
+	})
+	return nil
+}
+
+// changePassword sets userID's password and revokes all of their sessions
+// except the one the request was made from, if any. Outstanding password
+// reset links stop working as well.
+func (cfg *apiConfig) changePassword(r *http.Request, userID uuid.UUID, password string) error {
+	hashedPassword, err := cfg.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	// Tokens without a session, such as personal access tokens, keep no
+	// session alive.
+	currentSession := uuid.Nil
+	if claims := cfg.sessionClaims(r); claims != nil {
+		currentSession, _ = uuid.Parse(claims.SessionID)
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		return err
+	}
+	err = qtx.InvalidatePasswordResetTokensForUser(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+	revoked, err := qtx.RevokeOtherSessionsForUser(r.Context(), database.RevokeOtherSessionsForUserParams{
+		UserID:   userID,
+		FamilyID: currentSession,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditPasswordChanged,
+		UserID:   userID,
+		Metadata: map[string]any{"sessions_revoked": revoked},
+	})
+	return nil
+}
+
+// sessionClaims returns the claims of the request's bearer token when it is a
+// session access token, and nil otherwise.
+func (cfg *apiConfig) sessionClaims(r *http.Request) *auth.Claims {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return nil
+	}
+	claims, err := cfg.keys.ValidateJWT(token)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
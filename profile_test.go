@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+func TestUpdateProfile_Email(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+
+	// A stolen access token must not be enough to take over the account.
+	rec := ts.request("PATCH", "/api/users/me", user.Token, map[string]string{"email": "heisenberg@breakingbad.com"})
+	expectStatus(t, rec, 401)
+	rec = ts.request("PATCH", "/api/users/me", user.Token, map[string]string{
+		"email":            "heisenberg@breakingbad.com",
+		"current_password": "pollos hermanos",
+	})
+	expectStatus(t, rec, 401)
+
+	rec = ts.request("PATCH", "/api/users/me", user.Token, map[string]string{
+		"email":            "heisenberg@breakingbad.com",
+		"current_password": testPassword,
+	})
+	expectStatus(t, rec, 200)
+	updated := decodeResponse[User](t, rec)
+	if updated.Email != "walt@breakingbad.com" || updated.PendingEmail != "heisenberg@breakingbad.com" {
+		t.Errorf("expected heisenberg@breakingbad.com pending verification, got %s", rec.Body)
+	}
+	if notice := ts.waitForMail("walt@breakingbad.com"); !strings.Contains(notice.Body, "heisenberg@breakingbad.com") {
+		t.Errorf("expected the old address told about the new one, got %q", notice.Body)
+	}
+}
+
+func TestUpdateProfile_EmailThrottled(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+
+	for range testAccountPolicy.MaxFailures {
+		rec := ts.request("PATCH", "/api/users/me", user.Token, map[string]string{
+			"email":            "heisenberg@breakingbad.com",
+			"current_password": "pollos hermanos",
+		})
+		expectStatus(t, rec, 401)
+	}
+	rec := ts.request("PATCH", "/api/users/me", user.Token, map[string]string{
+		"email":            "heisenberg@breakingbad.com",
+		"current_password": testPassword,
+	})
+	expectStatus(t, rec, 429)
+}
+
+func TestUpdateProfile_EmailWithoutPassword(t *testing.T) {
+	ts := newTestServer(t)
+	user, err := ts.cfg.db.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          "walt@breakingbad.com",
+		HashedPassword: unsetPassword,
+	})
+	if err != nil {
+		t.Fatalf("cannot create user: %v", err)
+	}
+
+	stale := ts.loginWithoutPassword(user)
+	_, err = ts.cfg.conn.Exec("UPDATE refresh_tokens SET session_started_at = session_started_at - interval '1 hour' WHERE user_id = $1", user.ID)
+	if err != nil {
+		t.Fatalf("cannot backdate session: %v", err)
+	}
+	rec := ts.request("PATCH", "/api/users/me", stale.Token, map[string]string{"email": "heisenberg@breakingbad.com"})
+	expectStatus(t, rec, 403)
+
+	fresh := ts.loginWithoutPassword(user)
+	rec = ts.request("PATCH", "/api/users/me", fresh.Token, map[string]string{"email": "heisenberg@breakingbad.com"})
+	expectStatus(t, rec, 200)
+}
+
+func TestUpdateProfile_RejectsPersonalAccessToken(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+	rec := ts.request("POST", "/api/tokens", user.Token, map[string]any{"name": "bot", "scopes": []string{auth.ScopeAccountWrite}})
+	expectStatus(t, rec, 201)
+	token := decodeResponse[PersonalAccessToken](t, rec)
+
+	rec = ts.request("PATCH", "/api/users/me", token.Token, map[string]string{
+		"email":            "heisenberg@breakingbad.com",
+		"current_password": testPassword,
+	})
+	expectStatus(t, rec, 401)
+	rec = ts.request("PUT", "/api/users", token.Token, map[string]string{
+		"email":    "heisenberg@breakingbad.com",
+		"password": testPassword,
+	})
+	expectStatus(t, rec, 401)
+}
+
+func TestLegacyUpdateUser(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+
+	// Existing clients send the current password along with the email.
+	rec := ts.request("PUT", "/api/users", user.Token, map[string]string{
+		"email":    "walt@breakingbad.com",
+		"password": testPassword,
+	})
+	expectStatus(t, rec, 200)
+
+	// It no longer changes the password, and a stolen access token must not
+	// be enough to change the email.
+	rec = ts.request("PUT", "/api/users", user.Token, map[string]string{
+		"email":    "walt@breakingbad.com",
+		"password": "pollos hermanos",
+	})
+	expectStatus(t, rec, 401)
+	rec = ts.request("PUT", "/api/users", user.Token, map[string]string{"email": "heisenberg@breakingbad.com"})
+	expectStatus(t, rec, 401)
+	ts.login("walt@breakingbad.com")
+
+	rec = ts.request("PUT", "/api/users", user.Token, map[string]string{
+		"email":    "heisenberg@breakingbad.com",
+		"password": testPassword,
+	})
+	expectStatus(t, rec, 200)
+	updated := decodeResponse[User](t, rec)
+	if updated.Email != "walt@breakingbad.com" || updated.PendingEmail != "heisenberg@breakingbad.com" {
+		t.Errorf("expected heisenberg@breakingbad.com pending verification, got %s", rec.Body)
+	}
+}
+
+func TestLegacyUpdateUser_Impersonated(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.createAdmin("gus@lospollos.com")
+	user := ts.createUser("walt@breakingbad.com")
+	impersonation := ts.impersonate(admin, user, true)
+
+	rec := ts.request("PUT", "/api/users", impersonation.Token, map[string]string{"email": "heisenberg@breakingbad.com"})
+	expectStatus(t, rec, 403)
+	rec = ts.request("PUT", "/api/users", impersonation.Token, map[string]string{"email": "walt@breakingbad.com"})
+	expectStatus(t, rec, 403)
+}
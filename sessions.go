@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// Session is a login on one device: a refresh token family and the metadata
+// recorded when its current token was issued.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+}
+
+func (cfg *apiConfig) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r, auth.ScopeAccountRead)
+	if !ok {
+		return
+	}
+
+	refreshTokens, err := cfg.db.ListSessionsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch sessions")
+		return
+	}
+
+	sessions := []Session{}
+	for _, refreshToken := range refreshTokens {
+		sessions = append(sessions, dbRefreshTokenToSessionStruct(refreshToken))
+	}
+
+	respondWithJson(w, 200, sessions)
+}
+
+func (cfg *apiConfig) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r, auth.ScopeAccountWrite)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid session id")
+		return
+	}
+
+	revoked, err := cfg.db.RevokeSession(r.Context(), database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot revoke session")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditSessionRevoked,
+		UserID:   userID,
+		Metadata: map[string]any{"session_id": sessionID},
+	})
+	respondWithJson(w, 204, nil)
+}
+
+func (cfg *apiConfig) handleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r, auth.ScopeAccountWrite)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.RevokeAllRefreshTokensForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot revoke sessions")
+		return
+	}
+
+	cfg.audit(r, auditEvent{Type: auditAllSessionsRevoked, UserID: userID})
+	respondWithJson(w, 204, nil)
+}
+
+func dbRefreshTokenToSessionStruct(refreshToken database.RefreshToken) Session {
+	return Session{
+		ID:         refreshToken.FamilyID,
+		CreatedAt:  refreshToken.SessionStartedAt,
+		LastUsedAt: refreshToken.CreatedAt,
+		ExpiresAt:  refreshToken.ExpiresAt,
+		UserAgent:  refreshToken.UserAgent,
+		IPAddress:  refreshToken.IpAddress,
+	}
+}
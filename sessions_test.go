@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+type testTokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+
+	rec := ts.request("POST", "/api/refresh", user.RefreshToken, nil)
+	expectStatus(t, rec, 200)
+	rotated := decodeResponse[testTokenPair](t, rec)
+	if rotated.RefreshToken == user.RefreshToken {
+		t.Errorf("expected a new refresh token")
+	}
+	if ts.sessionID(rotated.Token) != ts.sessionID(user.Token) {
+		t.Errorf("expected the rotated token to stay in the same session")
+	}
+
+	rec = ts.request("GET", "/api/sessions", rotated.Token, nil)
+	expectStatus(t, rec, 200)
+	rec = ts.request("POST", "/api/refresh", rotated.RefreshToken, nil)
+	expectStatus(t, rec, 200)
+}
+
+func TestRefresh_ReuseRevokesSession(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+
+	rec := ts.request("POST", "/api/refresh", user.RefreshToken, nil)
+	expectStatus(t, rec, 200)
+	rotated := decodeResponse[testTokenPair](t, rec)
+
+	// Replaying a rotated refresh token means it leaked, so the whole
+	// session goes, including the tokens rotated from it.
+	rec = ts.request("POST", "/api/refresh", user.RefreshToken, nil)
+	expectStatus(t, rec, 401)
+	rec = ts.request("POST", "/api/refresh", rotated.RefreshToken, nil)
+	expectStatus(t, rec, 401)
+	rec = ts.request("GET", "/api/sessions", rotated.Token, nil)
+	expectStatus(t, rec, 401)
+}
+
+func TestRevokeSession(t *testing.T) {
+	ts := newTestServer(t)
+	laptop := ts.createUser("walt@breakingbad.com")
+	phone := ts.login("walt@breakingbad.com")
+
+	rec := ts.request("GET", "/api/sessions", laptop.Token, nil)
+	expectStatus(t, rec, 200)
+	if sessions := decodeResponse[[]Session](t, rec); len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	rec = ts.request("DELETE", "/api/sessions/"+ts.sessionID(phone.Token), laptop.Token, nil)
+	expectStatus(t, rec, 204)
+
+	// The revoked session's access token stops working right away, not when
+	// it expires.
+	rec = ts.request("GET", "/api/sessions", phone.Token, nil)
+	expectStatus(t, rec, 401)
+	rec = ts.request("POST", "/api/refresh", phone.RefreshToken, nil)
+	expectStatus(t, rec, 401)
+
+	rec = ts.request("GET", "/api/sessions", laptop.Token, nil)
+	expectStatus(t, rec, 200)
+	if sessions := decodeResponse[[]Session](t, rec); len(sessions) != 1 {
+		t.Errorf("expected 1 session, got %d", len(sessions))
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	ts := newTestServer(t)
+	laptop := ts.createUser("walt@breakingbad.com")
+	phone := ts.login("walt@breakingbad.com")
+
+	rec := ts.request("DELETE", "/api/sessions", laptop.Token, nil)
+	expectStatus(t, rec, 204)
+
+	for _, user := range []User{laptop, phone} {
+		rec = ts.request("GET", "/api/sessions", user.Token, nil)
+		expectStatus(t, rec, 401)
+		rec = ts.request("POST", "/api/refresh", user.RefreshToken, nil)
+		expectStatus(t, rec, 401)
+	}
+}
@@ -0,0 +1,2 @@
+-- name: CancelUserDeletion :exec
+UPDATE users SET deletion_requested_at = NULL, updated_at = NOW() WHERE id = $1;
\ No newline at end of file
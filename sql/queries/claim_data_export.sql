@@ -0,0 +1,10 @@
+-- name: ClaimDataExport :one
+UPDATE data_exports SET status = 'processing', updated_at = NOW()
+WHERE id = (
+    SELECT id FROM data_exports
+    WHERE status = 'pending'
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready', size_bytes = $2, expires_at = $3, completed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
\ No newline at end of file
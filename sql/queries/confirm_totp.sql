@@ -0,0 +1,2 @@
+-- name: ConfirmTOTP :exec
+UPDATE user_totp SET confirmed_at = NOW(), updated_at = NOW() WHERE user_id = $1;
\ No newline at end of file
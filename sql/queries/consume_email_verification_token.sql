@@ -0,0 +1,4 @@
+-- name: ConsumeEmailVerificationToken :one
+UPDATE email_verification_tokens SET used_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and used_at IS NULL
+RETURNING *;
\ No newline at end of file
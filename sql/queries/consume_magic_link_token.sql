@@ -0,0 +1,4 @@
+-- name: ConsumeMagicLinkToken :one
+UPDATE magic_link_tokens SET used_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and used_at IS NULL
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: ConsumeOAuthAuthorizationCode :one
+UPDATE oauth_authorization_codes SET used_at = NOW()
+WHERE code_hash = $1 and expires_at > NOW() and used_at IS NULL
+RETURNING *;
\ No newline at end of file
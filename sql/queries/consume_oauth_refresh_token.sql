@@ -0,0 +1,4 @@
+-- name: ConsumeOAuthRefreshToken :one
+UPDATE oauth_refresh_tokens SET revoked_at = NOW()
+WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 and provider = $2 and expires_at > NOW()
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, event_type, actor_id, user_id, ip_address, user_agent, metadata, created_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW()
+       );
\ No newline at end of file
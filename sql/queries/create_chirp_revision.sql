@@ -0,0 +1,10 @@
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES (
+        gen_random_uuid(),
+        $1,
+        $2,
+        $3,
+        NOW()
+       )
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: CreateContentFilter :one
+INSERT INTO content_filters (id, kind, pattern, action, created_by, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, NOW(), NOW()
+       )
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: CreateContentFilterHit :exec
+INSERT INTO content_filter_hits (id, filter_id, chirp_id, user_id, action, matched_text, created_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, NOW()
+       );
\ No newline at end of file
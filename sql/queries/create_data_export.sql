@@ -0,0 +1,6 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, user_id, status, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, 'pending', NOW(), NOW()
+       )
+RETURNING *;
\ No newline at end of file
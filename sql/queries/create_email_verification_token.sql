@@ -0,0 +1,5 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, user_id, email, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, NOW()
+       );
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: CreateLoginAttempts :exec
+INSERT INTO login_attempts (key, failures, last_failure_at)
+VALUES ($1, 0, $2)
+ON CONFLICT (key) DO NOTHING;
\ No newline at end of file
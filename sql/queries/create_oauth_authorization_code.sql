@@ -0,0 +1,5 @@
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, grant_id, redirect_uri, code_challenge, scopes, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, $5, $6, NOW()
+       );
\ No newline at end of file
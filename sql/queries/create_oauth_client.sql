@@ -0,0 +1,6 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, owner_id, name, secret_hash, redirect_uris, scopes, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW()
+       )
+RETURNING *;
\ No newline at end of file
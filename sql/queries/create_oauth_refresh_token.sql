@@ -0,0 +1,5 @@
+-- name: CreateOAuthRefreshToken :exec
+INSERT INTO oauth_refresh_tokens (token_hash, grant_id, scopes, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, NOW()
+       );
\ No newline at end of file
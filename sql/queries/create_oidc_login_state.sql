@@ -0,0 +1,5 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+VALUES (
+        $1, $2, $3, $4, $5, NOW()
+       );
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, expires_at, created_at)
+VALUES (
+        $1, $2, $3, NOW()
+       );
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW()
+       )
+RETURNING *;
@@ -0,0 +1,5 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (code_hash, user_id, created_at)
+VALUES (
+        $1, $2, NOW()
+       );
\ No newline at end of file
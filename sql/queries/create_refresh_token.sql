@@ -1,10 +1,16 @@
 -- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens (
-                            token, user_id, expires_at, created_at, updated_at
+                            token_hash, user_id, expires_at, family_id, parent_token_hash,
+                            user_agent, ip_address, session_started_at, created_at, updated_at
 ) VALUES (
           $1,
           $2,
             $3,
+          $4,
+          $5,
+          $6,
+          $7,
+          $8,
           NOW(),
           NOW()
-         ) RETURNING *;
\ No newline at end of file
+         ) RETURNING *;
@@ -0,0 +1,5 @@
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (id, user_id, provider, subject, email, created_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, $4, NOW()
+       );
\ No newline at end of file
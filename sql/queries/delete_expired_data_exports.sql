@@ -0,0 +1,2 @@
+-- name: DeleteExpiredDataExports :exec
+DELETE FROM data_exports WHERE expires_at < NOW();
\ No newline at end of file
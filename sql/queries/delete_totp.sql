@@ -0,0 +1,2 @@
+-- name: DeleteTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1;
\ No newline at end of file
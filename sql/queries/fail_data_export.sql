@@ -0,0 +1,2 @@
+-- name: FailDataExport :exec
+UPDATE data_exports SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW() WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: FindRefreshToken :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1;
\ No newline at end of file
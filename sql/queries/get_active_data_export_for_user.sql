@@ -0,0 +1,5 @@
+-- name: GetActiveDataExportForUser :one
+SELECT * FROM data_exports
+WHERE user_id = $1 and status IN ('pending', 'processing')
+ORDER BY created_at DESC
+LIMIT 1;
\ No newline at end of file
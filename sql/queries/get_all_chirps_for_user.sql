@@ -1,2 +1,4 @@
 -- name: GetAllChirpsForUser :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
\ No newline at end of file
+SELECT * FROM chirps
+WHERE user_id = $1 and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+ORDER BY created_at;
\ No newline at end of file
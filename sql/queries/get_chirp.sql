@@ -1,2 +1,3 @@
 -- name: GetChirp :one
-SELECT * FROM chirps WHERE id = $1;
\ No newline at end of file
+SELECT * FROM chirps
+WHERE chirps.id = $1 and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL);
\ No newline at end of file
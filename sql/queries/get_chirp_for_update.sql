@@ -0,0 +1,6 @@
+-- name: GetChirpForUpdate :one
+SELECT sqlc.embed(chirps),
+       chirps.created_at > NOW() - make_interval(secs => sqlc.arg('edit_window_seconds')) AS editable
+FROM chirps
+WHERE chirps.id = sqlc.arg('id')
+FOR UPDATE;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: GetContentFilter :one
+SELECT * FROM content_filters WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: GetDataExport :one
+SELECT * FROM data_exports WHERE id = $1 and user_id = $2;
\ No newline at end of file
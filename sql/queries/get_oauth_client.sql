@@ -0,0 +1,2 @@
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients WHERE id = $1;
\ No newline at end of file
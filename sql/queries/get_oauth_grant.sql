@@ -0,0 +1,4 @@
+-- name: GetOAuthGrant :one
+SELECT * FROM oauth_grants
+WHERE oauth_grants.id = $1 and revoked_at IS NULL
+  and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL);
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetOAuthRefreshToken :one
+SELECT * FROM oauth_refresh_tokens
+WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetPersonalAccessToken :one
+SELECT * FROM personal_access_tokens
+WHERE token_hash = $1 and revoked_at IS NULL and (expires_at IS NULL or expires_at > NOW())
+  and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL);
\ No newline at end of file
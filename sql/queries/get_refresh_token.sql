@@ -1,2 +1,2 @@
 -- name: GetRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token = $1 and expires_at > NOW() and revoked_at IS NULL;
\ No newline at end of file
+SELECT * FROM refresh_tokens WHERE token_hash = $1 and expires_at > NOW() and revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetUserByIdentity :one
+SELECT * FROM users
+WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 and subject = $2);
\ No newline at end of file
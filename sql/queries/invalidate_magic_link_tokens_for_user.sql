@@ -0,0 +1,2 @@
+-- name: InvalidateMagicLinkTokensForUser :exec
+UPDATE magic_link_tokens SET used_at = NOW() WHERE user_id = $1 and used_at IS NULL;
\ No newline at end of file
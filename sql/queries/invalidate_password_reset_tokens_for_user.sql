@@ -0,0 +1,2 @@
+-- name: InvalidatePasswordResetTokensForUser :exec
+UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 and used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: IsRecentSession :one
+SELECT EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE family_id = sqlc.arg('family_id') and user_id = sqlc.arg('user_id') and revoked_at IS NULL
+      and session_started_at > NOW() - make_interval(secs => sqlc.arg('max_age_seconds'))
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: IsSessionActive :one
+SELECT EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE family_id = $1 and user_id = $2 and revoked_at IS NULL and expires_at > NOW()
+);
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  and (sqlc.narg('actor_id')::uuid IS NULL OR actor_id = sqlc.narg('actor_id'))
+  and (sqlc.narg('event_type')::text IS NULL OR event_type = sqlc.narg('event_type'))
+  and (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+  and (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
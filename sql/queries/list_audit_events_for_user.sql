@@ -0,0 +1,2 @@
+-- name: ListAuditEventsForUser :many
+SELECT * FROM audit_events WHERE user_id = $1 ORDER BY created_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: ListChirpRevisionsForUser :many
+SELECT chirp_revisions.* FROM chirp_revisions
+JOIN chirps ON chirps.id = chirp_revisions.chirp_id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.chirp_id, chirp_revisions.replaced_at;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- name: ListChirps :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  and (sqlc.narg('after_created_at')::timestamp IS NULL OR (created_at, id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+  and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
\ No newline at end of file
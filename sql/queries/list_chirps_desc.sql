@@ -0,0 +1,7 @@
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  and (sqlc.narg('before_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+  and user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
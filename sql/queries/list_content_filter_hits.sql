@@ -0,0 +1,9 @@
+-- name: ListContentFilterHits :many
+SELECT content_filter_hits.*, content_filters.kind, content_filters.pattern
+FROM content_filter_hits
+JOIN content_filters ON content_filters.id = content_filter_hits.filter_id
+WHERE (sqlc.narg('filter_id')::uuid IS NULL OR content_filter_hits.filter_id = sqlc.narg('filter_id'))
+  and (sqlc.narg('action')::text IS NULL OR content_filter_hits.action = sqlc.narg('action'))
+  and (sqlc.narg('since')::timestamp IS NULL OR content_filter_hits.created_at >= sqlc.narg('since'))
+ORDER BY content_filter_hits.created_at DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: ListContentFilterStats :many
+SELECT filter_id, COUNT(*) AS hit_count, MAX(created_at)::timestamp AS last_hit_at
+FROM content_filter_hits
+GROUP BY filter_id;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: ListContentFilters :many
+SELECT * FROM content_filters ORDER BY created_at;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: ListOAuthClientsForOwner :many
+SELECT * FROM oauth_clients WHERE owner_id = $1 ORDER BY created_at DESC;
\ No newline at end of file
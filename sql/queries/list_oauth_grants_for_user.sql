@@ -0,0 +1,6 @@
+-- name: ListOAuthGrantsForUser :many
+SELECT oauth_grants.client_id, oauth_clients.name AS client_name, oauth_grants.scopes, oauth_grants.created_at, oauth_grants.updated_at
+FROM oauth_grants
+JOIN oauth_clients ON oauth_clients.id = oauth_grants.client_id
+WHERE oauth_grants.user_id = $1 and oauth_grants.revoked_at IS NULL
+ORDER BY oauth_grants.created_at DESC;
\ No newline at end of file
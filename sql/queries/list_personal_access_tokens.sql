@@ -0,0 +1,2 @@
+-- name: ListPersonalAccessTokens :many
+SELECT * FROM personal_access_tokens WHERE user_id = $1 and revoked_at IS NULL ORDER BY created_at DESC;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: ListSessionsForUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1 and expires_at > NOW() and revoked_at IS NULL
+ORDER BY created_at DESC;
\ No newline at end of file
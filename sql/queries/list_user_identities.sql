@@ -0,0 +1,2 @@
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at;
\ No newline at end of file
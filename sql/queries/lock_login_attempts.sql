@@ -0,0 +1,2 @@
+-- name: LockLoginAttempts :one
+SELECT * FROM login_attempts WHERE key = $1 FOR UPDATE;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: PurgeDeletedUsers :many
+DELETE FROM users WHERE deletion_requested_at < $1 RETURNING id;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (key, failures, last_failure_at)
+VALUES (
+        sqlc.arg('key'), 1, sqlc.arg('failed_at')
+       )
+ON CONFLICT (key) DO UPDATE
+    SET failures = CASE
+                       WHEN login_attempts.last_failure_at < sqlc.arg('forget_before') THEN 1
+                       ELSE login_attempts.failures + 1
+                   END,
+        last_failure_at = EXCLUDED.last_failure_at
+RETURNING *;
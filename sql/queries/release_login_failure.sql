@@ -0,0 +1,2 @@
+-- name: ReleaseLoginFailure :exec
+UPDATE login_attempts SET failures = GREATEST(failures - 1, 0) WHERE key = $1;
\ No newline at end of file
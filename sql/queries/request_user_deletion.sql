@@ -0,0 +1,2 @@
+-- name: RequestUserDeletion :one
+UPDATE users SET deletion_requested_at = NOW(), updated_at = NOW() WHERE id = $1 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: RequeueStaleDataExports :exec
+UPDATE data_exports SET status = 'pending', updated_at = NOW() WHERE status = 'processing' and updated_at < $1;
\ No newline at end of file
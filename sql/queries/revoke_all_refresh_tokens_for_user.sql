@@ -0,0 +1,2 @@
+-- name: RevokeAllRefreshTokensForUser :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE user_id = $1 and revoked_at IS NULL;
\ No newline at end of file
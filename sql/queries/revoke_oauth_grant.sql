@@ -0,0 +1,3 @@
+-- name: RevokeOAuthGrant :execrows
+UPDATE oauth_grants SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 and client_id = $2 and revoked_at IS NULL;
\ No newline at end of file
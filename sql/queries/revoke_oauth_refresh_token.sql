@@ -0,0 +1,2 @@
+-- name: RevokeOAuthRefreshToken :exec
+UPDATE oauth_refresh_tokens SET revoked_at = NOW() WHERE token_hash = $1 and revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 and user_id = $2 and revoked_at IS NULL;
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: RevokeRefreshToken :exec
-UPDATE refresh_tokens SET revoked_at = NOW() WHERE token = $1;
\ No newline at end of file
+UPDATE refresh_tokens SET revoked_at = NOW() WHERE token_hash = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 and user_id = $2 and revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- name: SearchChirps :many
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.edited_at,
+       ts_rank(chirps.search_vector, query)::real AS rank,
+       ts_headline('english', chirps.body, query, sqlc.arg('headline_options'))::text AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg('query')) query
+WHERE chirps.search_vector @@ query
+  and (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+  and (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since'))
+  and (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until'))
+  and (sqlc.narg('after_rank')::real IS NULL
+       OR (ts_rank(chirps.search_vector, query), chirps.created_at, chirps.id) < (sqlc.narg('after_rank'), sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+  and chirps.user_id IN (SELECT id FROM users WHERE deletion_requested_at IS NULL)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: SetUserRole :one
+UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: SetUserRoleByEmail :one
+UPDATE users SET role = $2, updated_at = NOW() WHERE email = $1 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: UpdateContentFilter :one
+UPDATE content_filters SET kind = $2, pattern = $3, action = $4, updated_at = NOW() WHERE id = $1 RETURNING *;
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: UpgradeUser :one
-UPDATE users SET is_chirpy_red = true where id = $1 RETURNING *;
\ No newline at end of file
+UPDATE users SET is_chirpy_red = true, updated_at = NOW() where id = $1 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: UpsertOAuthGrant :one
+INSERT INTO oauth_grants (id, user_id, client_id, scopes, created_at, updated_at)
+VALUES (
+        gen_random_uuid(), $1, $2, $3, NOW(), NOW()
+       )
+ON CONFLICT (user_id, client_id) WHERE revoked_at IS NULL
+DO UPDATE SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_grants.scopes || EXCLUDED.scopes)), updated_at = NOW()
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- name: UpsertTOTP :one
+INSERT INTO user_totp (user_id, secret_ciphertext, created_at, updated_at)
+VALUES (
+        $1, $2, NOW(), NOW()
+       )
+ON CONFLICT (user_id) DO UPDATE
+    SET secret_ciphertext = EXCLUDED.secret_ciphertext, last_used_step = 0, updated_at = NOW()
+    WHERE user_totp.confirmed_at IS NULL
+RETURNING *;
@@ -0,0 +1,2 @@
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes SET used_at = NOW() WHERE code_hash = $1 and user_id = $2 and used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: UseTOTPStep :execrows
+UPDATE user_totp SET last_used_step = $2, updated_at = NOW() WHERE user_id = $1 and last_used_step < $2;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: VerifyUserEmail :one
+UPDATE users SET email = $2, email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 RETURNING *;
\ No newline at end of file
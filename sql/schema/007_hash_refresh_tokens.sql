@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+ALTER TABLE refresh_tokens RENAME COLUMN parent_token TO parent_token_hash;
+
+-- Existing rows hold plaintext tokens; rehash them in place so live sessions
+-- keep working while the plaintext disappears from the table.
+UPDATE refresh_tokens
+SET token_hash        = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex'),
+    parent_token_hash = encode(sha256(convert_to(parent_token_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- Digests cannot be turned back into tokens, so every session is invalidated.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens RENAME COLUMN parent_token_hash TO parent_token;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE user_totp (
+    user_id uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_ciphertext BYTEA NOT NULL,
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE mfa_recovery_codes (
+    code_hash TEXT PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE mfa_recovery_codes;
+DROP TABLE user_totp;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE audit_events (
+    id uuid PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    actor_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    ip_address TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_events_user_id_idx ON audit_events (user_id, created_at);
+CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id, created_at);
+
+-- +goose Down
+DROP TABLE audit_events;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Audit events outlive the users they mention, so they keep plain ids
+-- instead of foreign keys that would rewrite or drop them.
+ALTER TABLE audit_events DROP CONSTRAINT audit_events_actor_id_fkey;
+ALTER TABLE audit_events DROP CONSTRAINT audit_events_user_id_fkey;
+
+CREATE INDEX audit_events_event_type_idx ON audit_events (event_type, created_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- +goose Down
+DROP TRIGGER audit_events_append_only ON audit_events;
+DROP FUNCTION audit_events_append_only();
+DROP INDEX audit_events_event_type_idx;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
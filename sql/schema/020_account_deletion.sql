@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN deletion_requested_at TIMESTAMP;
+
+CREATE INDEX users_deletion_requested_at_idx ON users (deletion_requested_at) WHERE deletion_requested_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX users_deletion_requested_at_idx;
+ALTER TABLE users DROP COLUMN deletion_requested_at;
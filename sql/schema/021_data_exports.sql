@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE data_exports (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT,
+    size_bytes BIGINT,
+    expires_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id);
+CREATE INDEX data_exports_pending_idx ON data_exports (created_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE data_exports;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE content_filters (
+    id uuid PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('word', 'phrase', 'regex')),
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('redact', 'reject', 'flag')),
+    created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (kind, pattern)
+);
+
+CREATE TABLE content_filter_hits (
+    id uuid PRIMARY KEY,
+    filter_id uuid NOT NULL REFERENCES content_filters(id) ON DELETE CASCADE,
+    chirp_id uuid REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    matched_text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX content_filter_hits_filter_id_idx ON content_filter_hits (filter_id, created_at);
+CREATE INDEX content_filter_hits_created_at_idx ON content_filter_hits (created_at);
+
+-- The words chirps were always cleaned of.
+INSERT INTO content_filters (id, kind, pattern, action, created_at, updated_at)
+VALUES (gen_random_uuid(), 'word', 'kerfuffle', 'redact', NOW(), NOW()),
+       (gen_random_uuid(), 'word', 'sharbert', 'redact', NOW(), NOW()),
+       (gen_random_uuid(), 'word', 'fornax', 'redact', NOW(), NOW());
+
+-- +goose Down
+DROP TABLE content_filter_hits;
+DROP TABLE content_filters;
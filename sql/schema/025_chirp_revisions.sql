@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN edited_at TIMESTAMP;
+
+CREATE TABLE chirp_revisions (
+    id uuid PRIMARY KEY,
+    chirp_id uuid NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions (chirp_id, replaced_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
+ALTER TABLE chirps DROP COLUMN edited_at;
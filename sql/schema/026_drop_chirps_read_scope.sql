@@ -0,0 +1,12 @@
+-- +goose Up
+-- Chirps are public, so chirps:read never guarded anything and is no longer
+-- offered. Drop it from everything already granted it.
+UPDATE personal_access_tokens SET scopes = array_remove(scopes, 'chirps:read');
+UPDATE oauth_clients SET scopes = array_remove(scopes, 'chirps:read');
+UPDATE oauth_grants SET scopes = array_remove(scopes, 'chirps:read');
+UPDATE oauth_authorization_codes SET scopes = array_remove(scopes, 'chirps:read');
+UPDATE oauth_refresh_tokens SET scopes = array_remove(scopes, 'chirps:read');
+
+-- +goose Down
+-- The scope granted nothing, so there is nothing to restore.
+SELECT 1;
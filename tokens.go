@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sidis405/chirpy/internal/auth"
+	"github.com/sidis405/chirpy/internal/database"
+)
+
+// PersonalAccessToken is a long-lived, scoped token for scripts and bots. The
+// token itself is only ever returned when it is created.
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Token      string     `json:"token,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (cfg *apiConfig) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 500, "cannot unmarshal data")
+		return
+	}
+
+	if params.Name == "" {
+		respondWithError(w, 400, "name is required")
+		return
+	}
+	if !auth.ValidScopes(params.Scopes) {
+		respondWithError(w, 400, "invalid scopes")
+		return
+	}
+	if params.ExpiresInDays < 0 {
+		respondWithError(w, 400, "invalid expiry")
+		return
+	}
+
+	token, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		respondWithError(w, 500, "cannot generate token")
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if params.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, params.ExpiresInDays), Valid: true}
+	}
+
+	pat, err := cfg.db.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:    userID,
+		Name:      params.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    params.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot create token")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditAccessTokenCreated,
+		UserID:   userID,
+		Metadata: map[string]any{"token_id": pat.ID, "name": pat.Name, "scopes": pat.Scopes},
+	})
+
+	response := dbTokenToTokenStruct(pat)
+	response.Token = token
+	respondWithJson(w, 201, response)
+}
+
+func (cfg *apiConfig) handleListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	pats, err := cfg.db.ListPersonalAccessTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "cannot fetch tokens")
+		return
+	}
+
+	tokens := []PersonalAccessToken{}
+	for _, pat := range pats {
+		tokens = append(tokens, dbTokenToTokenStruct(pat))
+	}
+
+	respondWithJson(w, 200, tokens)
+}
+
+func (cfg *apiConfig) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireOwnSession(w, r)
+	if !ok {
+		return
+	}
+
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 400, "invalid token id")
+		return
+	}
+
+	revoked, err := cfg.db.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "cannot revoke token")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "not found")
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:     auditAccessTokenRevoked,
+		UserID:   userID,
+		Metadata: map[string]any{"token_id": tokenID},
+	})
+
+	respondWithJson(w, 204, nil)
+}
+
+func dbTokenToTokenStruct(pat database.PersonalAccessToken) PersonalAccessToken {
+	token := PersonalAccessToken{
+		ID:        pat.ID,
+		Name:      pat.Name,
+		Scopes:    pat.Scopes,
+		CreatedAt: pat.CreatedAt,
+	}
+	if pat.ExpiresAt.Valid {
+		token.ExpiresAt = &pat.ExpiresAt.Time
+	}
+	if pat.LastUsedAt.Valid {
+		token.LastUsedAt = &pat.LastUsedAt.Time
+	}
+	return token
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sidis405/chirpy/internal/auth"
+)
+
+func TestCreateToken_Scopes(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.createUser("walt@breakingbad.com")
+
+	rec := ts.request("POST", "/api/tokens", user.Token, map[string]any{"name": "reader", "scopes": []string{"chirps:read"}})
+	expectStatus(t, rec, 400)
+
+	rec = ts.request("POST", "/api/tokens", user.Token, map[string]any{"name": "bot", "scopes": []string{auth.ScopeChirpsWrite}})
+	expectStatus(t, rec, 201)
+	token := decodeResponse[PersonalAccessToken](t, rec)
+
+	rec = ts.request("POST", "/api/chirps", token.Token, map[string]string{"body": "Yeah, science!"})
+	expectStatus(t, rec, 201)
+	rec = ts.request("GET", "/api/sessions", token.Token, nil)
+	expectStatus(t, rec, 403)
+}